@@ -83,6 +83,18 @@ func runServer(cmd *cobra.Command, args []string) {
 
 	// Create application manager
 	appManager := apps.NewManager(cfg.Applications)
+	if err := appManager.ConfigureLogSinks(cfg.GetNodeName(), cfg.LogSinks); err != nil {
+		logrus.WithError(err).Fatal("Failed to configure log sinks")
+	}
+
+	// Adopt the applications which were still running when tailon last stopped
+	if cfg.DataDir != "" {
+		if err := appManager.RestoreState(cfg.DataDir); err != nil {
+			logrus.WithError(err).Fatal("Failed to restore application state")
+		}
+
+		appManager.SetShutdownMode(cfg.ShutdownMode)
+	}
 
 	// Create servers
 	var apiServer *api.Server
@@ -186,6 +198,9 @@ func runServer(cmd *cobra.Command, args []string) {
 		logrus.Info("Local HTTP server disabled")
 	}
 
+	// Start any applications which should be running as soon as tailon is
+	appManager.Autostart(ctx)
+
 	// Wait for interrupt signal
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -212,6 +227,15 @@ func runServer(cmd *cobra.Command, args []string) {
 
 	cancelRequests()
 
+	// Stop all of the applications we are managing so that they are not orphaned, unless they
+	// should be left running for the next instance of tailon to adopt
+	appCtx, cancelApps := context.WithTimeout(context.Background(), cfg.GetShutdownTimeout())
+	defer cancelApps()
+
+	if err := appManager.Shutdown(appCtx); err != nil {
+		logrus.WithError(err).Error("Application shutdown failed")
+	}
+
 	logrus.Info("Server stopped")
 }
 
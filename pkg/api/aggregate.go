@@ -0,0 +1,218 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/sierrasoftworks/tailon/pkg/apps"
+	"github.com/sierrasoftworks/tailon/pkg/userctx"
+	"github.com/sirupsen/logrus"
+)
+
+// HandleAggregatedLogs returns the logs of several applications merged in timestamp order
+// (JSON or Server-Sent Events). Applications which the user cannot view are left out.
+func (s *Server) HandleAggregatedLogs(w http.ResponseWriter, r *http.Request) {
+	user := userctx.FromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var names []string
+	for _, name := range strings.Split(r.URL.Query().Get("apps"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		http.Error(w, "apps must list at least one application", http.StatusBadRequest)
+		return
+	}
+
+	// Filter applications based on user permissions
+	viewerRule := AppViewer()
+	authorized := make([]string, 0, len(names))
+	for _, name := range names {
+		vars := map[string]string{"app_name": name}
+		if viewerRule.GetActiveRole(vars, user).IsAllowed() {
+			authorized = append(authorized, name)
+		}
+	}
+
+	if len(authorized) == 0 {
+		http.Error(w, "Forbidden: insufficient permissions", http.StatusForbidden)
+		return
+	}
+
+	for _, name := range authorized {
+		if _, err := s.manager.GetApp(name); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+	}
+
+	query, err := parseLogQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Sequence numbers are only meaningful within a single application's logs
+	if query.After > 0 || query.Before > 0 {
+		http.Error(w, "after and before are not supported when reading the logs of several applications", http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseLogFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	spans := r.URL.Query().Get("spans") == "true"
+
+	if r.Header.Get("Accept") == "text/event-stream" || r.URL.Query().Get("stream") == "true" {
+		s.handleAggregatedLogsSSE(w, r, authorized, query, filter, spans)
+		return
+	}
+
+	var logs []AppLogLineV1
+	for _, name := range authorized {
+		appLogs, err := s.queryLogs(name, query, filter)
+		if err != nil {
+			logrus.WithError(err).WithField("app", name).Error("Failed to read logs")
+			http.Error(w, "Failed to read logs", http.StatusInternalServerError)
+			return
+		}
+
+		logs = append(logs, tagLogs(name, styleLogs(appLogs, spans))...)
+	}
+
+	// Each application's limit applies to the merged logs as well
+	logs = mergeLogs(logs)
+	if query.Limit > 0 && len(logs) > query.Limit {
+		logs = logs[len(logs)-query.Limit:]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(logs); err != nil {
+		logrus.WithError(err).Error("Failed to encode logs response")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleAggregatedLogsSSE streams the logs of several applications as Server-Sent Events
+func (s *Server) handleAggregatedLogsSSE(w http.ResponseWriter, r *http.Request, names []string, query apps.LogQuery, filter *logFilter, spans bool) {
+	// Set SSE headers
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// Send existing logs first, subscribing to each application's new logs at the same time
+	var backlog []AppLogLineV1
+	streams := make([]*logStream, 0, len(names))
+	for _, name := range names {
+		logs, sub, err := s.manager.SubscribeLogs(name, query)
+		if err != nil {
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
+			flusher.Flush()
+			return
+		}
+		defer sub.Close()
+
+		stream := &logStream{app: name, sub: sub, window: filter.window()}
+		for _, log := range logs {
+			backlog = append(backlog, tagLogs(name, styleLogs(stream.accept(log), spans))...)
+		}
+
+		streams = append(streams, stream)
+	}
+
+	for _, log := range mergeLogs(backlog) {
+		writeAppLogEvent(w, log)
+	}
+	flusher.Flush()
+
+	// New lines are streamed in the order in which they arrive
+	type streamLine struct {
+		stream *logStream
+		log    apps.LogLine
+	}
+
+	lines := make(chan streamLine)
+	for _, stream := range streams {
+		go func() {
+			for log := range stream.sub.Lines() {
+				select {
+				case lines <- streamLine{stream, log}:
+				case <-r.Context().Done():
+					return
+				}
+			}
+		}()
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line := <-lines:
+			stream := line.stream
+			for _, log := range tagLogs(stream.app, styleLogs(stream.next(line.log), spans)) {
+				writeAppLogEvent(w, log)
+			}
+
+			missed, lost, err := stream.catchUp(s.manager)
+			if err != nil {
+				return
+			}
+
+			if lost > 0 {
+				fmt.Fprintf(w, "event: lag\ndata: {\"app\":%q,\"dropped\":%d}\n\n", stream.app, lost)
+			}
+
+			for _, log := range tagLogs(stream.app, styleLogs(missed, spans)) {
+				writeAppLogEvent(w, log)
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+// writeAppLogEvent writes the log line as a Server-Sent Event. Unlike a single application's
+// log events, these have no ID as sequence numbers are only unique within an application.
+func writeAppLogEvent(w http.ResponseWriter, log AppLogLineV1) {
+	data, _ := json.Marshal(log)
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+// tagLogs labels each of the application's log lines with its name
+func tagLogs(name string, logs []apps.LogLine) []AppLogLineV1 {
+	tagged := make([]AppLogLineV1, len(logs))
+	for i, log := range logs {
+		tagged[i] = AppLogLineV1{App: name, LogLine: log}
+	}
+	return tagged
+}
+
+// mergeLogs sorts the log lines of several applications by their timestamps, keeping each
+// application's lines in the order they were logged.
+func mergeLogs(logs []AppLogLineV1) []AppLogLineV1 {
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].Timestamp.Before(logs[j].Timestamp)
+	})
+	return logs
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/apps"
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/sierrasoftworks/tailon/pkg/userctx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAggregateServer(t *testing.T) (*Server, *apps.Manager) {
+	manager := apps.NewManager([]config.ApplicationConfig{
+		{
+			Name: "frontend",
+			Path: "/bin/sh",
+			Args: []string{"-c", "echo request; sleep 0.2; echo response"},
+		},
+		{
+			Name: "backend",
+			Path: "/bin/sh",
+			Args: []string{"-c", "sleep 0.1; echo handling; sleep 10 & wait"},
+		},
+		{
+			Name: "secret",
+			Path: "/bin/sh",
+			Args: []string{"-c", "echo classified"},
+		},
+	})
+	server := NewServer(manager)
+
+	for _, name := range []string{"frontend", "backend", "secret"} {
+		require.NoError(t, manager.StartApp(context.Background(), name))
+		t.Cleanup(func() { manager.StopApp(context.Background(), name) })
+	}
+
+	require.NoError(t, manager.WaitForExit(context.Background(), "frontend"))
+	return server, manager
+}
+
+func aggregateRequest(query string) *http.Request {
+	req := httptest.NewRequest("GET", "/api/v1/logs"+query, nil)
+	user := &userctx.User{
+		ID:          "test-user",
+		DisplayName: "Test User",
+		ApplicationRoles: map[string]userctx.Role{
+			"frontend": userctx.RoleViewer,
+			"backend":  userctx.RoleOperator,
+		},
+	}
+	return req.WithContext(userctx.WithUser(req.Context(), user))
+}
+
+func TestHandleAggregatedLogs(t *testing.T) {
+	server, _ := setupAggregateServer(t)
+
+	recorder := httptest.NewRecorder()
+	server.HandleAggregatedLogs(recorder, aggregateRequest("?apps=frontend,backend,secret&source=stdout"))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var logs []AppLogLineV1
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &logs))
+
+	var lines []string
+	for i, log := range logs {
+		lines = append(lines, log.App+": "+log.Message)
+		if i > 0 {
+			assert.False(t, log.Timestamp.Before(logs[i-1].Timestamp), "lines are in timestamp order")
+		}
+	}
+
+	// The secret app's logs are left out as the user cannot view them
+	assert.Equal(t, []string{"frontend: request", "backend: handling", "frontend: response"}, lines)
+
+	recorder = httptest.NewRecorder()
+	server.HandleAggregatedLogs(recorder, aggregateRequest("?apps=frontend,backend&source=stdout&limit=1"))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &logs))
+	require.Len(t, logs, 1)
+	assert.Equal(t, "response", logs[0].Message)
+}
+
+func TestHandleAggregatedLogsErrors(t *testing.T) {
+	server, _ := setupAggregateServer(t)
+
+	tests := []struct {
+		query string
+		code  int
+	}{
+		{"", http.StatusBadRequest},
+		{"?apps=secret", http.StatusForbidden},
+		{"?apps=frontend&after=3", http.StatusBadRequest},
+		{"?apps=frontend&regex=(", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		server.HandleAggregatedLogs(recorder, aggregateRequest(tt.query))
+		assert.Equal(t, tt.code, recorder.Code, tt.query)
+	}
+
+	// Users with access to every application are told if one doesn't exist
+	req := httptest.NewRequest("GET", "/api/v1/logs?apps=frontend,missing", nil)
+	recorder := httptest.NewRecorder()
+	server.HandleAggregatedLogs(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestHandleAggregatedLogsSSE(t *testing.T) {
+	server, manager := setupAggregateServer(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := &userctx.User{ID: "test-user", ApplicationRoles: map[string]userctx.Role{"*": userctx.RoleViewer}}
+		server.HandleAggregatedLogs(w, r.WithContext(userctx.WithUser(r.Context(), user)))
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", ts.URL+"?apps=frontend,backend&source=audit&stream=true", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	readLine := func() AppLogLineV1 {
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				var log AppLogLineV1
+				require.NoError(t, json.Unmarshal([]byte(data), &log))
+				return log
+			}
+		}
+
+		t.Fatal("the stream ended unexpectedly")
+		return AppLogLineV1{}
+	}
+
+	// The backlog of audit entries from both applications is sent first
+	seen := map[string]bool{}
+	for len(seen) < 2 {
+		log := readLine()
+		seen[log.App] = true
+	}
+
+	// Followed by new lines as they are logged
+	require.NoError(t, manager.StopApp(context.Background(), "backend"))
+	for {
+		log := readLine()
+		if log.App == "backend" && strings.Contains(log.Message, "Stopped application") {
+			return
+		}
+	}
+}
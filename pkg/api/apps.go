@@ -29,14 +29,7 @@ func (s *Server) HandleGetApps(w http.ResponseWriter, r *http.Request) {
 		role := viewerRule.GetActiveRole(vars, user)
 		if role.IsAllowed() {
 			// Create response object
-			response := ApplicationResponseV1{
-				Config:         appData.Config,
-				State:          appData.State,
-				PID:            appData.PID,
-				LastExitCode:   appData.LastExitCode,
-				StateChangedBy: appData.StateChangedBy,
-				StateChangedAt: appData.StateChangedAt,
-			}
+			response := NewApplicationResponseV1(appData)
 
 			// Check if user has admin role for this app - if not, remove env variables
 			if role != userctx.RoleAdmin {
@@ -72,14 +65,7 @@ func (s *Server) HandleGetApp(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create response object
-	response := ApplicationResponseV1{
-		Config:         app.Config,
-		State:          app.State,
-		PID:            app.PID,
-		LastExitCode:   app.LastExitCode,
-		StateChangedBy: app.StateChangedBy,
-		StateChangedAt: app.StateChangedAt,
-	}
+	response := NewApplicationResponseV1(app)
 
 	// Check if user has admin role for this app - if not, remove env variables
 	if role != userctx.RoleAdmin {
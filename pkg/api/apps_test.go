@@ -1,14 +1,18 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/sierrasoftworks/tailon/pkg/apps"
 	"github.com/sierrasoftworks/tailon/pkg/userctx"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHandleGetApps(t *testing.T) {
@@ -49,6 +53,11 @@ func TestHandleGetApp(t *testing.T) {
 	config, ok := response["config"].(map[string]interface{})
 	assert.True(t, ok)
 	assert.Equal(t, "test-app", config["name"])
+
+	logBuffer, ok := response["log_buffer"].(map[string]interface{})
+	require.True(t, ok, "the detail endpoint should report log buffer usage")
+	assert.Equal(t, float64(0), logBuffer["lines"])
+	assert.Equal(t, float64(1000), logBuffer["max_lines"])
 }
 
 func TestHandleGetAppEnvironmentFiltering(t *testing.T) {
@@ -141,3 +150,30 @@ func TestHandleGetAppNotFound(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, recorder.Code)
 }
+
+func TestHandleGetAppFailure(t *testing.T) {
+	server, manager := SetupTestServer()
+
+	// The crash-app exits immediately and is restarted until it is considered to be crash looping
+	require.NoError(t, manager.StartApp(context.Background(), "crash-app"))
+
+	require.Eventually(t, func() bool {
+		app, _ := manager.GetApp("crash-app")
+		return app.State == apps.StateFailed
+	}, 2*time.Second, 10*time.Millisecond)
+
+	req := httptest.NewRequest("GET", "/api/v1/apps/crash-app", nil)
+	req = mux.SetURLVars(req, map[string]string{"app_name": "crash-app"})
+	recorder := httptest.NewRecorder()
+
+	server.HandleGetApp(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response ApplicationResponseV1
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, apps.StateFailed, response.State)
+	require.NotNil(t, response.Failure)
+	assert.NotEmpty(t, response.Failure.Reason)
+	assert.NotEmpty(t, response.Failure.ExitCodes)
+	assert.Contains(t, response.Failure.StderrTail, "crashing")
+}
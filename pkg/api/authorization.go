@@ -1,9 +1,11 @@
 package api
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/sierrasoftworks/tailon/pkg/apps"
 	"github.com/sierrasoftworks/tailon/pkg/userctx"
 )
 
@@ -35,6 +37,15 @@ func (s *Server) RequireAuthorization(w http.ResponseWriter, r *http.Request, ru
 	return ""
 }
 
+// cascadeContext returns the request's context, in which the manager may only start or stop the
+// dependencies and dependents of the application in the URL which the user may operate
+func (s *Server) cascadeContext(r *http.Request) context.Context {
+	user := userctx.FromContext(r.Context())
+	return apps.WithAuthorizer(r.Context(), func(name string) bool {
+		return user != nil && AppOperator().GetActiveRole(map[string]string{"app_name": name}, user).IsAllowed()
+	})
+}
+
 // AppRole creates a rule that requires a specific role or higher for an application
 type appRole struct {
 	roles map[userctx.Role]bool
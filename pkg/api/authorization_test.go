@@ -8,8 +8,10 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/sierrasoftworks/tailon/pkg/apps"
 	"github.com/sierrasoftworks/tailon/pkg/userctx"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAuthorizationRules(t *testing.T) {
@@ -265,6 +267,78 @@ func TestHandleStartAppAuthorization(t *testing.T) {
 	}
 }
 
+func TestHandleLifecycleDependencyAuthorization(t *testing.T) {
+	server, manager := SetupTestServer()
+	defer manager.Shutdown(context.Background())
+
+	// The user may operate the dependency, but may only view its dependent
+	dependencyOperator := &userctx.User{
+		ID:          "test-user",
+		DisplayName: "Test User",
+		ApplicationRoles: map[string]userctx.Role{
+			"ready-app":  userctx.RoleOperator,
+			"worker-app": userctx.RoleViewer,
+		},
+	}
+
+	// The user may operate the dependent, but may only view its dependency
+	dependentOperator := &userctx.User{
+		ID:          "test-user",
+		DisplayName: "Test User",
+		ApplicationRoles: map[string]userctx.Role{
+			"ready-app":  userctx.RoleViewer,
+			"worker-app": userctx.RoleOperator,
+		},
+	}
+
+	request := func(action, appName string, user *userctx.User) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/apps/"+appName+"/"+action, nil)
+		req = mux.SetURLVars(req, map[string]string{"app_name": appName})
+		req = req.WithContext(userctx.WithUser(req.Context(), user))
+
+		recorder := httptest.NewRecorder()
+		switch action {
+		case "start":
+			server.HandleStartApp(recorder, req)
+		case "stop":
+			server.HandleStopApp(recorder, req)
+		case "restart":
+			server.HandleRestartApp(recorder, req)
+		}
+
+		return recorder
+	}
+
+	// Dependencies aren't started on behalf of users who can't operate them
+	recorder := request("start", "worker-app", dependentOperator)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "ready-app")
+
+	app, err := manager.GetApp("ready-app")
+	require.NoError(t, err)
+	assert.Equal(t, apps.StateNotRunning, app.State)
+
+	_, err = manager.StartAppWithDependencies(context.Background(), "worker-app")
+	require.NoError(t, err)
+
+	// Dependents aren't stopped on behalf of users who can't operate them
+	for _, action := range []string{"stop", "restart"} {
+		recorder = request(action, "ready-app", dependencyOperator)
+		assert.Equal(t, http.StatusForbidden, recorder.Code, action)
+		assert.Contains(t, recorder.Body.String(), "worker-app", action)
+	}
+
+	for _, name := range []string{"ready-app", "worker-app"} {
+		app, err := manager.GetApp(name)
+		require.NoError(t, err)
+		assert.True(t, app.IsRunning(), "%s should still be running", name)
+	}
+
+	// Stopping a dependent doesn't involve its dependencies
+	recorder = request("stop", "worker-app", dependentOperator)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
 func TestRequireAuthorizationWithMultipleRules(t *testing.T) {
 	server, _ := SetupTestServer()
 
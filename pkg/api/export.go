@@ -0,0 +1,233 @@
+package api
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sierrasoftworks/tailon/pkg/apps"
+	"github.com/sirupsen/logrus"
+)
+
+// logExportFormat describes how exported logs are encoded
+type logExportFormat struct {
+	contentType string
+	extension   string
+	gzip        bool
+	write       func(w io.Writer, log apps.LogLine) error
+}
+
+// exportFlushLines is how many exported log lines are buffered before they are sent to the client
+const exportFlushLines = 1000
+
+// logExportFormats are the formats which logs can be exported in, by name
+var logExportFormats = map[string]logExportFormat{
+	"text":      {contentType: "text/plain; charset=utf-8", extension: "log", write: writeTextLog},
+	"ndjson":    {contentType: "application/x-ndjson", extension: "ndjson", write: writeJSONLog},
+	"ndjson.gz": {contentType: "application/gzip", extension: "ndjson.gz", gzip: true, write: writeJSONLog},
+}
+
+// HandleExportLogs downloads all of an application's available logs, including those
+// which have been persisted to disk, as a file which is streamed as it is read.
+func (s *Server) HandleExportLogs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	appName := vars["app_name"]
+
+	// Check authorization - require viewer role to view logs
+	if !s.RequireAuthorization(w, r, AppViewer()).IsAllowed() {
+		return
+	}
+
+	formatName := r.URL.Query().Get("format")
+	if formatName == "" {
+		formatName = "text"
+	}
+
+	format, ok := logExportFormats[formatName]
+	if !ok {
+		http.Error(w, "format must be one of text, ndjson or ndjson.gz", http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseLogFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Check if app exists first
+	if _, err := s.manager.GetApp(appName); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Lines are written as they are read, so the whole history is never held in memory
+	export := &logExport{
+		w:        w,
+		format:   format,
+		filename: fmt.Sprintf("%s-%s.%s", appName, time.Now().UTC().Format("20060102T150405Z"), format.extension),
+	}
+
+	window := filter.window()
+	err = s.manager.WalkLogs(appName, func(line apps.LogLine) error {
+		for _, log := range window.next(line) {
+			// Styled spans are left out, as each line's message already holds its text
+			if err := export.write(styleLog(log, false)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err == nil {
+		err = export.close()
+	}
+
+	if err != nil {
+		if !export.started() {
+			logrus.WithError(err).WithField("app", appName).Error("Failed to read logs")
+			http.Error(w, "Failed to read logs", http.StatusInternalServerError)
+			return
+		}
+
+		// The response has already started, so the client will see a truncated file
+		logrus.WithError(err).WithField("app", appName).Warn("Failed to export logs")
+	}
+}
+
+// logExport writes exported log lines to the response, flushing them to the client every
+// exportFlushLines lines
+type logExport struct {
+	w        http.ResponseWriter
+	format   logExportFormat
+	filename string
+	gz       *gzip.Writer
+	buffered *bufio.Writer
+	pending  int
+}
+
+// started returns true once the response has started
+func (e *logExport) started() bool {
+	return e.buffered != nil
+}
+
+// start writes the response's headers and prepares to encode log lines
+func (e *logExport) start() {
+	e.w.Header().Set("Content-Type", e.format.contentType)
+	e.w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": e.filename}))
+
+	var out io.Writer = e.w
+	if e.format.gzip {
+		e.gz = gzip.NewWriter(e.w)
+		out = e.gz
+	}
+
+	e.buffered = bufio.NewWriter(out)
+}
+
+// write encodes the log line, flushing the lines which have been written so far once there
+// are enough of them
+func (e *logExport) write(log apps.LogLine) error {
+	if !e.started() {
+		e.start()
+	}
+
+	if err := e.format.write(e.buffered, log); err != nil {
+		return err
+	}
+
+	e.pending++
+	if e.pending < exportFlushLines {
+		return nil
+	}
+
+	return e.flush()
+}
+
+// flush sends the lines which have been written so far to the client
+func (e *logExport) flush() error {
+	e.pending = 0
+	if err := e.buffered.Flush(); err != nil {
+		return err
+	}
+
+	if e.gz != nil {
+		if err := e.gz.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if flusher, ok := e.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+}
+
+// close finishes the export, which is an empty file if no lines were written
+func (e *logExport) close() error {
+	if !e.started() {
+		e.start()
+	}
+
+	if err := e.buffered.Flush(); err != nil {
+		return err
+	}
+
+	if e.gz != nil {
+		return e.gz.Close()
+	}
+
+	return nil
+}
+
+// writeTextLog writes the log line in a human readable form
+func writeTextLog(w io.Writer, log apps.LogLine) error {
+	var line strings.Builder
+	line.WriteString(log.Timestamp.UTC().Format(time.RFC3339Nano))
+	line.WriteString(" [")
+	line.WriteString(log.Source)
+	line.WriteString("]")
+
+	if log.Level != "" {
+		line.WriteString(" ")
+		line.WriteString(strings.ToUpper(log.Level))
+	}
+
+	line.WriteString(" ")
+	line.WriteString(log.Message)
+
+	keys := make([]string, 0, len(log.Fields))
+	for key := range log.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(&line, " %s=%q", key, log.Fields[key])
+	}
+
+	line.WriteString("\n")
+	_, err := io.WriteString(w, line.String())
+	return err
+}
+
+// writeJSONLog writes the log line as a line of JSON
+func writeJSONLog(w io.Writer, log apps.LogLine) error {
+	data, err := json.Marshal(log)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/sierrasoftworks/tailon/pkg/apps"
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupExportServer(t *testing.T) *Server {
+	manager := apps.NewManager([]config.ApplicationConfig{
+		{
+			Name: "chatty",
+			Path: "/bin/sh",
+			Args: []string{"-c", "seq 1 1500; echo oops >&2"},
+			LogStorage: &config.LogStorageConfig{
+				Directory: t.TempDir(),
+			},
+		},
+	})
+
+	require.NoError(t, manager.StartApp(context.Background(), "chatty"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "chatty"))
+
+	return NewServer(manager)
+}
+
+func exportLogs(server *Server, appName, query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/api/v1/apps/"+appName+"/logs/export"+query, nil)
+	req = mux.SetURLVars(req, map[string]string{"app_name": appName})
+	recorder := httptest.NewRecorder()
+
+	server.HandleExportLogs(recorder, req)
+	return recorder
+}
+
+func TestHandleExportLogsText(t *testing.T) {
+	server := setupExportServer(t)
+
+	recorder := exportLogs(server, "chatty", "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", recorder.Header().Get("Content-Type"))
+
+	disposition, params, err := mime.ParseMediaType(recorder.Header().Get("Content-Disposition"))
+	require.NoError(t, err)
+	assert.Equal(t, "attachment", disposition)
+	assert.True(t, strings.HasPrefix(params["filename"], "chatty-"))
+	assert.True(t, strings.HasSuffix(params["filename"], ".log"))
+
+	// The export includes the persisted history beyond the in-memory buffer
+	lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+	require.Greater(t, len(lines), 1500)
+	assert.Contains(t, lines[0], "[audit]")
+	// stdout and stderr are read independently, so only the order within each is known
+	assert.Contains(t, recorder.Body.String(), " [stdout] 1\n")
+	assert.Less(t, strings.Index(recorder.Body.String(), " [stdout] 1\n"), strings.Index(recorder.Body.String(), " [stdout] 2\n"))
+	assert.Contains(t, recorder.Body.String(), " [stderr] oops\n")
+}
+
+func TestHandleExportLogsNDJSON(t *testing.T) {
+	server := setupExportServer(t)
+
+	recorder := exportLogs(server, "chatty", "?format=ndjson&source=stderr")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/x-ndjson", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Header().Get("Content-Disposition"), ".ndjson")
+
+	var logs []apps.LogLine
+	scanner := bufio.NewScanner(recorder.Body)
+	for scanner.Scan() {
+		var log apps.LogLine
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &log))
+		logs = append(logs, log)
+	}
+
+	require.Len(t, logs, 1)
+	assert.Equal(t, "oops", logs[0].Message)
+}
+
+func TestHandleExportLogsGzip(t *testing.T) {
+	server := setupExportServer(t)
+
+	recorder := exportLogs(server, "chatty", "?format=ndjson.gz&source=stdout")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/gzip", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Header().Get("Content-Disposition"), ".ndjson.gz")
+	assert.True(t, recorder.Flushed, "lines should be sent as they are read, rather than all at once")
+
+	reader, err := gzip.NewReader(recorder.Body)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	require.Len(t, lines, 1500)
+
+	var last apps.LogLine
+	require.NoError(t, json.Unmarshal(lines[len(lines)-1], &last))
+	assert.Equal(t, "1500", last.Message)
+}
+
+func TestHandleExportLogsErrors(t *testing.T) {
+	server, _ := SetupTestServer()
+
+	assert.Equal(t, http.StatusBadRequest, exportLogs(server, "test-app", "?format=xml").Code)
+	assert.Equal(t, http.StatusBadRequest, exportLogs(server, "test-app", "?since=yesterday").Code)
+	assert.Equal(t, http.StatusNotFound, exportLogs(server, "missing", "").Code)
+}
+
+func TestWriteTextLog(t *testing.T) {
+	var out strings.Builder
+	log := apps.LogLine{
+		Timestamp: testLogLines("x")[0].Timestamp,
+		Message:   "query failed",
+		Source:    "stdout",
+		Level:     "error",
+		Fields:    map[string]string{"table": "orders", "code": "7"},
+	}
+
+	require.NoError(t, writeTextLog(&out, log))
+	assert.Equal(t, "2025-08-07T12:00:00Z [stdout] ERROR query failed code=\"7\" table=\"orders\"\n", out.String())
+}
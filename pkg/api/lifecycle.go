@@ -2,10 +2,11 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
-	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/sierrasoftworks/tailon/pkg/apps"
 	"github.com/sirupsen/logrus"
 )
 
@@ -19,14 +20,19 @@ func (s *Server) HandleStartApp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.manager.StartApp(r.Context(), appName); err != nil {
+	started, err := s.manager.StartAppWithDependencies(s.cascadeContext(r), appName)
+	if err != nil {
 		logrus.WithError(err).WithField("app", appName).Error("Failed to start application")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeLifecycleError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if !s.waitForReady(w, r, appName) {
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "started"})
+	json.NewEncoder(w).Encode(StartResponseV1{Status: "started", StartedDependencies: started})
 }
 
 // HandleStopApp stops an application
@@ -44,14 +50,14 @@ func (s *Server) HandleStopApp(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 	if force {
-		err = s.manager.ForceStopApp(r.Context(), appName)
+		err = s.manager.ForceStopApp(s.cascadeContext(r), appName)
 	} else {
-		err = s.manager.StopApp(r.Context(), appName)
+		err = s.manager.StopApp(s.cascadeContext(r), appName)
 	}
 
 	if err != nil {
 		logrus.WithError(err).WithField("app", appName).WithField("force", force).Error("Failed to stop application")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeLifecycleError(w, err, http.StatusBadRequest)
 		return
 	}
 
@@ -64,7 +70,7 @@ func (s *Server) HandleStopApp(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"status": status})
 }
 
-// HandleRestartApp restarts an application (stop then start)
+// HandleRestartApp restarts an application (stop then start), along with its dependents
 func (s *Server) HandleRestartApp(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	appName := vars["app_name"]
@@ -82,21 +88,52 @@ func (s *Server) HandleRestartApp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Try to stop the app if it's running (ignore errors if already stopped)
-	if err := s.manager.StopApp(r.Context(), appName); err != nil {
-		logrus.WithField("app", appName).Debug("App was not running during restart")
-	}
+	// The applications which depend on it are stopped first and started again afterwards
+	started, restarted, err := s.manager.RestartApp(s.cascadeContext(r), appName)
+	if err != nil {
+		logrus.WithError(err).WithField("app", appName).Error("Failed to restart application")
 
-	// Give the app a moment to stop completely
-	time.Sleep(100 * time.Millisecond)
+		status := http.StatusBadRequest
+		if r.Context().Err() != nil {
+			// The application didn't stop, or its dependencies didn't become ready, in time
+			status = http.StatusServiceUnavailable
+		}
 
-	// Start the app
-	if err := s.manager.StartApp(r.Context(), appName); err != nil {
-		logrus.WithError(err).WithField("app", appName).Error("Failed to start application during restart")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeLifecycleError(w, err, status)
+		return
+	}
+
+	if !s.waitForReady(w, r, appName) {
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "restarted"})
+	json.NewEncoder(w).Encode(StartResponseV1{Status: "restarted", StartedDependencies: started, RestartedDependents: restarted})
+}
+
+// writeLifecycleError responds with 403 Forbidden if the request would have started or stopped
+// applications which the user may not operate, or otherwise with the provided status.
+func writeLifecycleError(w http.ResponseWriter, err error, status int) {
+	var forbidden *apps.ForbiddenError
+	if errors.As(err, &forbidden) {
+		status = http.StatusForbidden
+	}
+
+	http.Error(w, err.Error(), status)
+}
+
+// waitForReady waits for a newly started application to become ready if the caller
+// requested it with ?wait=true, returning false if an error response was written.
+func (s *Server) waitForReady(w http.ResponseWriter, r *http.Request, appName string) bool {
+	if r.URL.Query().Get("wait") != "true" {
+		return true
+	}
+
+	if err := s.manager.WaitReady(r.Context(), appName); err != nil {
+		logrus.WithError(err).WithField("app", appName).Error("Application did not become ready")
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return false
+	}
+
+	return true
 }
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/sierrasoftworks/tailon/pkg/apps"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -41,6 +42,54 @@ func TestHandleStartApp(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, recorder.Code)
 }
 
+func TestHandleStartAppWait(t *testing.T) {
+	server, manager := SetupTestServer()
+	defer manager.ForceStopApp(context.Background(), "ready-app")
+
+	req := httptest.NewRequest("POST", "/api/v1/apps/ready-app/start?wait=true", nil)
+	req = mux.SetURLVars(req, map[string]string{"app_name": "ready-app"})
+	recorder := httptest.NewRecorder()
+
+	server.HandleStartApp(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	app, err := manager.GetApp("ready-app")
+	require.NoError(t, err)
+	assert.Equal(t, apps.StateRunning, app.State)
+
+	// Applications without a ready_when pattern are ready as soon as they start
+	req = httptest.NewRequest("POST", "/api/v1/apps/test-app/start?wait=true", nil)
+	req = mux.SetURLVars(req, map[string]string{"app_name": "test-app"})
+	recorder = httptest.NewRecorder()
+
+	server.HandleStartApp(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestHandleStartAppDependencies(t *testing.T) {
+	server, manager := SetupTestServer()
+	defer manager.Shutdown(context.Background())
+
+	req := httptest.NewRequest("POST", "/api/v1/apps/worker-app/start", nil)
+	req = mux.SetURLVars(req, map[string]string{"app_name": "worker-app"})
+	recorder := httptest.NewRecorder()
+
+	server.HandleStartApp(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var response StartResponseV1
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, "started", response.Status)
+	assert.Equal(t, []string{"ready-app"}, response.StartedDependencies)
+
+	dependency, err := manager.GetApp("ready-app")
+	require.NoError(t, err)
+	assert.Equal(t, apps.StateRunning, dependency.State, "dependencies should be ready before their dependents start")
+}
+
 func TestHandleStopApp(t *testing.T) {
 	server, manager := SetupTestServer()
 
@@ -170,3 +219,33 @@ func TestHandleStopAppWithForce(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, recorder.Code)
 }
+
+func TestHandleRestartAppDependents(t *testing.T) {
+	server, manager := SetupTestServer()
+	defer manager.Shutdown(context.Background())
+
+	_, err := manager.StartAppWithDependencies(context.Background(), "worker-app")
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/api/v1/apps/ready-app/restart?wait=true", nil)
+	req = mux.SetURLVars(req, map[string]string{"app_name": "ready-app"})
+	recorder := httptest.NewRecorder()
+
+	server.HandleRestartApp(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var response StartResponseV1
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, "restarted", response.Status)
+	assert.Equal(t, []string{"worker-app"}, response.RestartedDependents)
+
+	// The dependent was stopped and started again once its dependency was ready
+	worker, err := manager.GetApp("worker-app")
+	require.NoError(t, err)
+	assert.True(t, worker.IsRunning())
+
+	runs, err := manager.GetRuns("worker-app")
+	require.NoError(t, err)
+	assert.Len(t, runs, 2)
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/apps"
+)
+
+// logFilter narrows down the log lines returned to a client. Lines outside of the selected
+// sources and time range are never returned, while the search terms select which of the
+// remaining lines match, optionally along with the lines surrounding them.
+type logFilter struct {
+	search  string
+	pattern *regexp.Regexp
+	levels  map[string]bool
+	fields  map[string]string
+	sources map[string]bool
+	run     uint64
+	since   time.Time
+	until   time.Time
+	context int
+}
+
+// parseLogFilter reads the log filter from the search, regex, level, field, source, run,
+// since, until and context query parameters.
+func parseLogFilter(r *http.Request) (*logFilter, error) {
+	params := r.URL.Query()
+	filter := &logFilter{
+		search: strings.ToLower(params.Get("search")),
+	}
+
+	if value := params.Get("regex"); value != "" {
+		pattern, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("regex is not a valid regular expression: %w", err)
+		}
+		filter.pattern = pattern
+	}
+
+	if value := params.Get("level"); value != "" {
+		filter.levels = make(map[string]bool)
+		for _, level := range strings.Split(value, ",") {
+			filter.levels[strings.ToLower(strings.TrimSpace(level))] = true
+		}
+	}
+
+	for _, value := range params["field"] {
+		key, fieldValue, ok := strings.Cut(value, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("field must be given as key=value")
+		}
+
+		if filter.fields == nil {
+			filter.fields = make(map[string]string)
+		}
+		filter.fields[key] = fieldValue
+	}
+
+	if value := params.Get("source"); value != "" {
+		filter.sources = make(map[string]bool)
+		for _, source := range strings.Split(value, ",") {
+			filter.sources[strings.TrimSpace(source)] = true
+		}
+	}
+
+	var err error
+	if value := params.Get("run"); value != "" {
+		if filter.run, err = strconv.ParseUint(value, 10, 64); err != nil || filter.run == 0 {
+			return nil, fmt.Errorf("run must be a positive integer")
+		}
+	}
+
+	if filter.since, err = parseLogTime(params.Get("since")); err != nil {
+		return nil, fmt.Errorf("since %w", err)
+	}
+
+	if filter.until, err = parseLogTime(params.Get("until")); err != nil {
+		return nil, fmt.Errorf("until %w", err)
+	}
+
+	if value := params.Get("context"); value != "" {
+		if filter.context, err = strconv.Atoi(value); err != nil || filter.context < 0 {
+			return nil, fmt.Errorf("context must be a non-negative integer")
+		}
+	}
+
+	return filter, nil
+}
+
+// parseLogTime parses either an RFC 3339 timestamp or a duration, which is taken to
+// mean that long ago.
+func parseLogTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("must be an RFC 3339 timestamp or a duration")
+}
+
+// active returns true if the filter excludes any log lines
+func (f *logFilter) active() bool {
+	return f.search != "" || f.pattern != nil || f.levels != nil || f.fields != nil || f.sources != nil || f.run != 0 || !f.since.IsZero() || !f.until.IsZero()
+}
+
+// inScope returns true if the line is from one of the selected sources and runs, and within
+// the time range
+func (f *logFilter) inScope(line apps.LogLine) bool {
+	if f.sources != nil && !f.sources[line.Source] {
+		return false
+	}
+
+	if f.run != 0 && line.Run != f.run {
+		return false
+	}
+
+	if !f.since.IsZero() && line.Timestamp.Before(f.since) {
+		return false
+	}
+
+	return f.until.IsZero() || !line.Timestamp.After(f.until)
+}
+
+// matches returns true if the line contains the search terms and has the selected level and fields
+func (f *logFilter) matches(line apps.LogLine) bool {
+	if f.levels != nil && !f.levels[line.Level] {
+		return false
+	}
+
+	for key, value := range f.fields {
+		if actual, exists := line.Fields[key]; !exists || actual != value {
+			return false
+		}
+	}
+
+	if f.search != "" && !strings.Contains(strings.ToLower(line.Message), f.search) {
+		return false
+	}
+
+	return f.pattern == nil || f.pattern.MatchString(line.Message)
+}
+
+// apply returns the lines which match the filter, along with their context
+func (f *logFilter) apply(lines []apps.LogLine) []apps.LogLine {
+	if !f.active() {
+		return lines
+	}
+
+	window := f.window()
+	selected := make([]apps.LogLine, 0, len(lines))
+	for _, line := range lines {
+		selected = append(selected, window.next(line)...)
+	}
+
+	return selected
+}
+
+// window returns a contextWindow which applies the filter to a stream of lines
+func (f *logFilter) window() *contextWindow {
+	return &contextWindow{filter: f}
+}
+
+// contextWindow applies a logFilter to lines one at a time, remembering enough of the
+// preceding lines to include the context around each match.
+type contextWindow struct {
+	filter *logFilter
+	before []apps.LogLine
+	after  int
+}
+
+// next returns the lines which should be shown now that the line has been seen
+func (c *contextWindow) next(line apps.LogLine) []apps.LogLine {
+	if !c.filter.inScope(line) {
+		return nil
+	}
+
+	if c.filter.matches(line) {
+		lines := append(c.before, line)
+		c.before = nil
+		c.after = c.filter.context
+		return lines
+	}
+
+	if c.after > 0 {
+		c.after--
+		return []apps.LogLine{line}
+	}
+
+	if c.filter.context > 0 {
+		c.before = append(c.before, line)
+		if len(c.before) > c.filter.context {
+			c.before = c.before[1:]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sierrasoftworks/tailon/pkg/apps"
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLogLines(messages ...string) []apps.LogLine {
+	start := time.Date(2025, 8, 7, 12, 0, 0, 0, time.UTC)
+	lines := make([]apps.LogLine, len(messages))
+	for i, message := range messages {
+		lines[i] = apps.LogLine{
+			Seq:       uint64(i + 1),
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Message:   message,
+			Source:    "stdout",
+		}
+	}
+	return lines
+}
+
+func messages(lines []apps.LogLine) []string {
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		result[i] = line.Message
+	}
+	return result
+}
+
+func parseTestFilter(t *testing.T, query string) *logFilter {
+	filter, err := parseLogFilter(httptest.NewRequest("GET", "/logs"+query, nil))
+	require.NoError(t, err)
+	return filter
+}
+
+func TestLogFilterSearch(t *testing.T) {
+	lines := testLogLines("starting", "ERROR: disk full", "retrying", "error: disk still full", "done")
+
+	assert.Equal(t, lines, parseTestFilter(t, "").apply(lines), "an empty filter returns every line")
+	assert.Equal(t, []string{"ERROR: disk full", "error: disk still full"}, messages(parseTestFilter(t, "?search=error").apply(lines)))
+	assert.Equal(t, []string{"error: disk still full"}, messages(parseTestFilter(t, "?regex=^error").apply(lines)))
+	assert.Equal(t, []string{"ERROR: disk full"}, messages(parseTestFilter(t, "?search=error&regex=disk%20full").apply(lines)))
+}
+
+func TestLogFilterContext(t *testing.T) {
+	lines := testLogLines("a", "b", "match 1", "c", "d", "e", "f", "match 2", "match 3", "g")
+
+	filtered := parseTestFilter(t, "?search=match&context=1").apply(lines)
+	assert.Equal(t, []string{"b", "match 1", "c", "f", "match 2", "match 3", "g"}, messages(filtered))
+
+	// Overlapping context isn't repeated
+	filtered = parseTestFilter(t, "?search=match&context=3").apply(lines)
+	assert.Equal(t, messages(lines), messages(filtered))
+}
+
+func TestLogFilterScope(t *testing.T) {
+	lines := testLogLines("one", "two", "three", "four")
+	lines[1].Source = "stderr"
+	lines[2].Source = "audit"
+
+	assert.Equal(t, []string{"two", "three"}, messages(parseTestFilter(t, "?source=stderr,audit").apply(lines)))
+	assert.Equal(t, []string{"two", "three"}, messages(parseTestFilter(t, "?since=2025-08-07T12:01:00Z&until=2025-08-07T12:02:00Z").apply(lines)))
+
+	lines[0].Run, lines[1].Run, lines[2].Run, lines[3].Run = 1, 1, 2, 2
+	assert.Equal(t, []string{"three", "four"}, messages(parseTestFilter(t, "?run=2").apply(lines)))
+
+	// Context lines never come from outside of the selected sources
+	assert.Equal(t, []string{"one", "four"}, messages(parseTestFilter(t, "?source=stdout&search=four&context=5").apply(lines)))
+
+	filter := parseTestFilter(t, "?since=1h")
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), filter.since, time.Minute)
+}
+
+func TestLogFilterStructured(t *testing.T) {
+	lines := testLogLines("started", "slow query", "query failed", "stopped")
+	lines[1].Level = "warn"
+	lines[1].Fields = map[string]string{"table": "users"}
+	lines[2].Level = "error"
+	lines[2].Fields = map[string]string{"table": "orders", "code": "7"}
+
+	assert.Equal(t, []string{"slow query", "query failed"}, messages(parseTestFilter(t, "?level=WARN,error").apply(lines)))
+	assert.Equal(t, []string{"query failed"}, messages(parseTestFilter(t, "?field=table=orders").apply(lines)))
+	assert.Equal(t, []string{"query failed"}, messages(parseTestFilter(t, "?field=table=orders&field=code=7").apply(lines)))
+	assert.Empty(t, parseTestFilter(t, "?field=table=orders&field=code=8").apply(lines))
+	assert.Equal(t, []string{"slow query", "query failed", "stopped"}, messages(parseTestFilter(t, "?level=error&context=1").apply(lines)))
+}
+
+func TestParseLogFilterInvalid(t *testing.T) {
+	for _, query := range []string{"?regex=(", "?since=yesterday", "?until=soon", "?context=-1", "?context=lots", "?field=table", "?field==orders", "?run=0", "?run=latest"} {
+		_, err := parseLogFilter(httptest.NewRequest("GET", "/logs"+query, nil))
+		assert.Error(t, err, query)
+	}
+}
+
+func TestHandleLogsSearch(t *testing.T) {
+	manager := apps.NewManager([]config.ApplicationConfig{
+		{
+			Name: "chatty",
+			Path: "/bin/sh",
+			Args: []string{"-c", "seq 1 20; echo failed >&2"},
+		},
+	})
+	server := NewServer(manager)
+
+	require.NoError(t, manager.StartApp(context.Background(), "chatty"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "chatty"))
+
+	getLogs := func(query string) ([]apps.LogLine, int) {
+		req := httptest.NewRequest("GET", "/api/v1/apps/chatty/logs"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"app_name": "chatty"})
+		recorder := httptest.NewRecorder()
+
+		server.HandleLogs(recorder, req)
+
+		var logs []apps.LogLine
+		if recorder.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &logs))
+		}
+		return logs, recorder.Code
+	}
+
+	logs, code := getLogs("?regex=^1[0-9]$&limit=3")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"17", "18", "19"}, messages(logs), "the limit applies to the matching lines")
+
+	logs, code = getLogs("?source=stderr&search=FAIL")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"failed"}, messages(logs))
+
+	logs, code = getLogs("?source=stdout&search=5&context=1")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"4", "5", "6", "14", "15", "16"}, messages(logs))
+
+	_, code = getLogs("?regex=[")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestHandleLogsSSESearch(t *testing.T) {
+	manager := apps.NewManager([]config.ApplicationConfig{
+		{
+			Name: "ticker",
+			Path: "/bin/sh",
+			Args: []string{"-c", "sleep 0.5; echo noise; echo wanted; sleep 10 & wait"},
+		},
+	})
+	server := NewServer(manager)
+
+	require.NoError(t, manager.StartApp(context.Background(), "ticker"))
+	defer manager.StopApp(context.Background(), "ticker")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.HandleLogs(w, mux.SetURLVars(r, map[string]string{"app_name": "ticker"}))
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", ts.URL+"?stream=true&source=stdout&search=want", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// The live tail is filtered too, so the first line streamed is the match
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			var line apps.LogLine
+			require.NoError(t, json.Unmarshal([]byte(data), &line))
+			assert.Equal(t, "wanted", line.Message)
+			return
+		}
+	}
+
+	t.Fatal("the matching line was never streamed")
+}
@@ -4,12 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"time"
+	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/sierrasoftworks/tailon/pkg/apps"
 	"github.com/sirupsen/logrus"
 )
 
+// defaultHistoryLimit is the number of persisted log lines returned when reading log
+// history without specifying a limit.
+const defaultHistoryLimit = 10000
+
 // HandleLogs returns application logs (JSON or Server-Sent Events)
 func (s *Server) HandleLogs(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -20,29 +25,101 @@ func (s *Server) HandleLogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	query, err := parseLogQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseLogFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	spans := r.URL.Query().Get("spans") == "true"
+
 	// Check if this is a Server-Sent Events request
 	if r.Header.Get("Accept") == "text/event-stream" || r.URL.Query().Get("stream") == "true" {
-		s.handleLogsSSE(w, r, appName)
+		s.handleLogsSSE(w, r, appName, query, filter, spans)
+		return
+	}
+
+	// Check if app exists first
+	if _, err := s.manager.GetApp(appName); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
 	// Regular JSON response
-	logs, err := s.manager.GetLogs(appName)
+	logs, err := s.queryLogs(appName, query, filter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		logrus.WithError(err).WithField("app", appName).Error("Failed to read logs")
+		http.Error(w, "Failed to read logs", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(logs); err != nil {
+	if err := json.NewEncoder(w).Encode(styleLogs(logs, spans)); err != nil {
 		logrus.WithError(err).Error("Failed to encode logs response")
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 }
 
+// queryLogs returns the application's log lines selected by the query which match the filter.
+// The query's limit applies to the lines which match the filter, rather than those searched.
+func (s *Server) queryLogs(appName string, query apps.LogQuery, filter *logFilter) ([]apps.LogLine, error) {
+	if filter.active() {
+		// The filter is applied as the lines are read, so that only those it selects are kept
+		query.Select = filter.window().next
+	}
+
+	return s.manager.QueryLogs(appName, query)
+}
+
+// parseLogQuery reads the range of log lines requested using the after, before, limit and
+// history query parameters.
+func parseLogQuery(r *http.Request) (apps.LogQuery, error) {
+	params := r.URL.Query()
+
+	// Persisted logs can be read back beyond the in-memory window
+	query := apps.LogQuery{History: params.Get("history") == "true"}
+	if query.History {
+		query.Limit = defaultHistoryLimit
+	}
+
+	var err error
+	if value := params.Get("after"); value != "" {
+		if query.After, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return query, fmt.Errorf("after must be a log sequence number")
+		}
+	}
+
+	// EventSource sends the ID of the last event it received when it reconnects
+	if value := r.Header.Get("Last-Event-ID"); value != "" {
+		if query.After, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return query, fmt.Errorf("Last-Event-ID must be a log sequence number")
+		}
+	}
+
+	if value := params.Get("before"); value != "" {
+		if query.Before, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return query, fmt.Errorf("before must be a log sequence number")
+		}
+	}
+
+	if value := params.Get("limit"); value != "" {
+		if query.Limit, err = strconv.Atoi(value); err != nil || query.Limit < 0 {
+			return query, fmt.Errorf("limit must be a non-negative integer")
+		}
+	}
+
+	return query, nil
+}
+
 // handleLogsSSE handles Server-Sent Events streaming for logs
-func (s *Server) handleLogsSSE(w http.ResponseWriter, r *http.Request, appName string) {
+func (s *Server) handleLogsSSE(w http.ResponseWriter, r *http.Request, appName string, query apps.LogQuery, filter *logFilter, spans bool) {
 	// Set SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -55,46 +132,133 @@ func (s *Server) handleLogsSSE(w http.ResponseWriter, r *http.Request, appName s
 		return
 	}
 
-	// Send existing logs first
-	logs, err := s.manager.GetLogs(appName)
+	// Send existing logs first, subscribing to new ones at the same time so that none are missed
+	logs, sub, err := s.manager.SubscribeLogs(appName, query)
 	if err != nil {
 		fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
 		flusher.Flush()
 		return
 	}
+	defer sub.Close()
 
+	// Lines are identified by their sequence numbers so that a reconnecting client (which
+	// sends the last ID it received as Last-Event-ID) only receives the lines it missed.
+	// The filter is applied to the live tail as well, so its matches are streamed too.
+	stream := &logStream{app: appName, sub: sub, window: filter.window(), lastSeq: query.After}
 	for _, log := range logs {
-		data, _ := json.Marshal(log)
-		fmt.Fprintf(w, "data: %s\n\n", data)
+		for _, line := range stream.accept(log) {
+			writeLogEvent(w, styleLog(line, spans))
+		}
 	}
 	flusher.Flush()
 
-	// Keep connection alive and send new logs
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	lastLogCount := len(logs)
-
 	for {
 		select {
 		case <-r.Context().Done():
 			return
-		case <-ticker.C:
-			currentLogs, err := s.manager.GetLogs(appName)
+		case log := <-sub.Lines():
+			for _, line := range stream.next(log) {
+				writeLogEvent(w, styleLog(line, spans))
+			}
+
+			// Deliver any other queued lines before flushing
+			for pending := len(sub.Lines()); pending > 0; pending-- {
+				for _, line := range stream.next(<-sub.Lines()) {
+					writeLogEvent(w, styleLog(line, spans))
+				}
+			}
+
+			missed, lost, err := stream.catchUp(s.manager)
 			if err != nil {
 				return
 			}
 
-			// Send only new logs
-			if len(currentLogs) > lastLogCount {
-				newLogs := currentLogs[lastLogCount:]
-				for _, log := range newLogs {
-					data, _ := json.Marshal(log)
-					fmt.Fprintf(w, "data: %s\n\n", data)
-				}
-				flusher.Flush()
-				lastLogCount = len(currentLogs)
+			if lost > 0 {
+				fmt.Fprintf(w, "event: lag\ndata: {\"dropped\":%d}\n\n", lost)
 			}
+
+			for _, line := range missed {
+				writeLogEvent(w, styleLog(line, spans))
+			}
+
+			flusher.Flush()
 		}
 	}
 }
+
+// styleLog returns the log line with the styled spans which make up its message if they
+// were requested, and otherwise without them. Lines from applications which preserve ANSI
+// escape sequences are converted, so their message is returned as plain text.
+func styleLog(log apps.LogLine, spans bool) apps.LogLine {
+	if !spans {
+		log.Spans = nil
+	} else if log.Spans == nil {
+		log.Message, log.Spans = apps.ParseANSI(log.Message)
+	}
+
+	return log
+}
+
+// styleLogs applies styleLog to each of the log lines
+func styleLogs(logs []apps.LogLine, spans bool) []apps.LogLine {
+	styled := make([]apps.LogLine, len(logs))
+	for i, log := range logs {
+		styled[i] = styleLog(log, spans)
+	}
+	return styled
+}
+
+// writeLogEvent writes the log line as a Server-Sent Event, identified by its sequence number
+func writeLogEvent(w http.ResponseWriter, log apps.LogLine) {
+	data, _ := json.Marshal(log)
+	fmt.Fprintf(w, "id: %d\ndata: %s\n\n", log.Seq, data)
+}
+
+// logStream tracks which of an application's log lines have been streamed to a client
+type logStream struct {
+	app     string
+	sub     *apps.LogSubscription
+	window  *contextWindow
+	lastSeq uint64
+}
+
+// accept returns the lines which should be streamed now that the line has been seen
+func (l *logStream) accept(log apps.LogLine) []apps.LogLine {
+	l.lastSeq = log.Seq
+	return l.window.next(log)
+}
+
+// next is like accept, but ignores lines which have already been seen
+func (l *logStream) next(log apps.LogLine) []apps.LogLine {
+	if log.Seq <= l.lastSeq {
+		return nil
+	}
+
+	return l.accept(log)
+}
+
+// catchUp recovers the lines which the client missed from the log buffer if its
+// subscription fell behind, along with the number of lines which had already been
+// discarded by the time it did.
+func (l *logStream) catchUp(manager *apps.Manager) ([]apps.LogLine, uint64, error) {
+	if l.sub.Dropped() == 0 {
+		return nil, 0, nil
+	}
+
+	missed, err := manager.QueryLogs(l.app, apps.LogQuery{After: l.lastSeq})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var lost uint64
+	if len(missed) > 0 && missed[0].Seq > l.lastSeq+1 {
+		lost = missed[0].Seq - l.lastSeq - 1
+	}
+
+	var lines []apps.LogLine
+	for _, log := range missed {
+		lines = append(lines, l.next(log)...)
+	}
+
+	return lines, lost, nil
+}
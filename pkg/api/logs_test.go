@@ -1,14 +1,19 @@
 package api
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/sierrasoftworks/tailon/pkg/apps"
+	"github.com/sierrasoftworks/tailon/pkg/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -75,3 +80,234 @@ func TestHandleLogsSSERouting(t *testing.T) {
 	// Stop the app
 	manager.StopApp(context.Background(), "test-logger")
 }
+
+func TestHandleLogsHistory(t *testing.T) {
+	manager := apps.NewManager([]config.ApplicationConfig{
+		{
+			Name: "chatty",
+			Path: "/bin/sh",
+			Args: []string{"-c", "seq 1 1500"},
+			LogStorage: &config.LogStorageConfig{
+				Directory: t.TempDir(),
+			},
+		},
+	})
+	server := NewServer(manager)
+
+	require.NoError(t, manager.StartApp(context.Background(), "chatty"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "chatty"))
+
+	getLogs := func(query string) []apps.LogLine {
+		req := httptest.NewRequest("GET", "/api/v1/apps/chatty/logs"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"app_name": "chatty"})
+		recorder := httptest.NewRecorder()
+
+		server.HandleLogs(recorder, req)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var logs []apps.LogLine
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &logs))
+		return logs
+	}
+
+	assert.Len(t, getLogs(""), 1000, "only the in-memory window is returned by default")
+
+	logs := getLogs("?history=true&limit=1400")
+	require.Len(t, logs, 1400)
+	assert.Equal(t, "102", logs[0].Message)
+
+	logs = getLogs("?history=true")
+	assert.Equal(t, "1", logs[1].Message, "the first line is the start audit entry")
+
+	req := httptest.NewRequest("GET", "/api/v1/apps/chatty/logs?history=true&limit=lots", nil)
+	req = mux.SetURLVars(req, map[string]string{"app_name": "chatty"})
+	recorder := httptest.NewRecorder()
+	server.HandleLogs(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestHandleLogsPaging(t *testing.T) {
+	server, manager := SetupTestServer()
+
+	require.NoError(t, manager.StartApp(context.Background(), "test-logger"))
+	defer manager.StopApp(context.Background(), "test-logger")
+	time.Sleep(200 * time.Millisecond)
+
+	getLogs := func(query string) ([]apps.LogLine, int) {
+		req := httptest.NewRequest("GET", "/api/v1/apps/test-logger/logs"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"app_name": "test-logger"})
+		recorder := httptest.NewRecorder()
+
+		server.HandleLogs(recorder, req)
+
+		var logs []apps.LogLine
+		if recorder.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &logs))
+		}
+		return logs, recorder.Code
+	}
+
+	all, code := getLogs("")
+	require.Equal(t, http.StatusOK, code)
+	require.GreaterOrEqual(t, len(all), 3)
+
+	// Without a cursor the most recent lines are returned
+	latest, code := getLogs("?limit=1")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, all[len(all)-1:], latest)
+
+	first, code := getLogs(fmt.Sprintf("?limit=1&after=%d", all[0].Seq))
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, first, 1)
+	assert.Equal(t, all[1].Seq, first[0].Seq)
+
+	next, code := getLogs(fmt.Sprintf("?limit=1&after=%d", first[0].Seq))
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, next, 1)
+	assert.Equal(t, all[2].Seq, next[0].Seq)
+
+	before, code := getLogs(fmt.Sprintf("?before=%d", all[1].Seq))
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, all[:1], before)
+
+	for _, query := range []string{"?after=-1", "?before=soon", "?limit=-5"} {
+		_, code := getLogs(query)
+		assert.Equal(t, http.StatusBadRequest, code, query)
+	}
+}
+
+func TestHandleLogsSSEFullBuffer(t *testing.T) {
+	manager := apps.NewManager([]config.ApplicationConfig{
+		{
+			Name: "chatty",
+			Path: "/bin/sh",
+			Args: []string{"-c", "seq 1 1500; sleep 1.5; echo after-full; sleep 10 & wait"},
+		},
+	})
+	server := NewServer(manager)
+
+	require.NoError(t, manager.StartApp(context.Background(), "chatty"))
+	defer manager.StopApp(context.Background(), "chatty")
+
+	require.Eventually(t, func() bool {
+		logs, _ := manager.GetLogs("chatty")
+		return len(logs) > 0 && logs[len(logs)-1].Message == "1500"
+	}, 2*time.Second, 10*time.Millisecond)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.HandleLogs(w, mux.SetURLVars(r, map[string]string{"app_name": "chatty"}))
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", ts.URL+"?stream=true", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Lines written once the buffer is full must still be streamed
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if strings.Contains(scanner.Text(), "after-full") {
+			return
+		}
+	}
+
+	t.Fatal("the line written after the buffer filled up was never streamed")
+}
+
+func TestHandleLogsSSELastEventID(t *testing.T) {
+	manager := apps.NewManager([]config.ApplicationConfig{
+		{
+			Name: "counter",
+			Path: "/bin/sh",
+			Args: []string{"-c", "seq 1 5"},
+		},
+	})
+	server := NewServer(manager)
+
+	require.NoError(t, manager.StartApp(context.Background(), "counter"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "counter"))
+
+	all, err := manager.GetLogs("counter")
+	require.NoError(t, err)
+	require.Greater(t, len(all), 3)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.HandleLogs(w, mux.SetURLVars(r, map[string]string{"app_name": "counter"}))
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// A reconnecting EventSource only receives the lines after the last one it saw
+	resume := all[len(all)-3]
+	req, err := http.NewRequestWithContext(ctx, "GET", ts.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Last-Event-ID", fmt.Sprintf("%d", resume.Seq))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(resp.Body)
+	for len(ids) < 2 && scanner.Scan() {
+		if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+
+	assert.Equal(t, []string{
+		fmt.Sprintf("%d", all[len(all)-2].Seq),
+		fmt.Sprintf("%d", all[len(all)-1].Seq),
+	}, ids)
+}
+
+func TestHandleLogsSpans(t *testing.T) {
+	output := `printf '\033[1;32mok\033[0m done\n'`
+	manager := apps.NewManager([]config.ApplicationConfig{
+		{Name: "preserve", Path: "/bin/sh", Args: []string{"-c", output}},
+		{Name: "convert", Path: "/bin/sh", Args: []string{"-c", output}, ANSI: config.ANSIConvert},
+	})
+	server := NewServer(manager)
+
+	stdout := func(name, query string) apps.LogLine {
+		req := httptest.NewRequest("GET", "/api/v1/apps/"+name+"/logs?source=stdout"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"app_name": name})
+		recorder := httptest.NewRecorder()
+		server.HandleLogs(recorder, req)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var logs []apps.LogLine
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &logs))
+		require.Len(t, logs, 1)
+		return logs[0]
+	}
+
+	for _, name := range []string{"preserve", "convert"} {
+		require.NoError(t, manager.StartApp(context.Background(), name))
+		require.NoError(t, manager.WaitForExit(context.Background(), name))
+	}
+
+	// Spans are only returned when they are requested
+	line := stdout("preserve", "")
+	assert.Equal(t, "\x1b[1;32mok\x1b[0m done", line.Message)
+	assert.Nil(t, line.Spans)
+
+	line = stdout("convert", "")
+	assert.Equal(t, "ok done", line.Message)
+	assert.Nil(t, line.Spans)
+
+	// Preserved escape sequences are converted on request
+	expected := []apps.LogSpan{{Text: "ok", Fg: "green", Bold: true}, {Text: " done"}}
+	for _, name := range []string{"preserve", "convert"} {
+		line = stdout(name, "&spans=true")
+		assert.Equal(t, "ok done", line.Message, name)
+		assert.Equal(t, expected, line.Spans, name)
+	}
+}
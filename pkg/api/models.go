@@ -16,8 +16,64 @@ type ApplicationResponseV1 struct {
 	LastExitCode   int                      `json:"last_exit_code"`
 	StateChangedBy *userctx.User            `json:"state_changed_by,omitempty"`
 	StateChangedAt *time.Time               `json:"state_changed_at,omitempty"`
+	RestartCount   int                      `json:"restart_count"`
+	NextRestartAt  *time.Time               `json:"next_restart_at,omitempty"`
+	Failure        *apps.FailureInfo        `json:"failure,omitempty"`
+	Health         *apps.HealthState        `json:"health,omitempty"`
+	// How the last process was terminated, if a signal terminated it
+	LastExitSignal         string `json:"last_exit_signal,omitempty"`
+	LastCoreDumped         bool   `json:"last_core_dumped,omitempty"`
+	LastSignalSentByTailon bool   `json:"last_signal_sent_by_tailon,omitempty"`
+
+	Processes         []apps.ProcessInfo   `json:"processes,omitempty"`
+	LeftoverProcesses []apps.ProcessInfo   `json:"leftover_processes,omitempty"`
+	LogBuffer         *apps.LogBufferUsage `json:"log_buffer,omitempty"`
+	// The number of secrets which have been redacted from the application's logs
+	Redactions uint64 `json:"redactions"`
+}
+
+// NewApplicationResponseV1 creates the API representation of an application
+func NewApplicationResponseV1(app *apps.Application) ApplicationResponseV1 {
+	return ApplicationResponseV1{
+		Config:         app.Config,
+		State:          app.State,
+		PID:            app.PID,
+		LastExitCode:   app.LastExitCode,
+		StateChangedBy: app.StateChangedBy,
+		StateChangedAt: app.StateChangedAt,
+		RestartCount:   app.RestartCount,
+		NextRestartAt:  app.NextRestartAt,
+		Failure:        app.Failure,
+		Health:         app.Health,
+
+		LastExitSignal:         app.LastExitSignal,
+		LastCoreDumped:         app.LastCoreDumped,
+		LastSignalSentByTailon: app.LastSignalSentByTailon,
+
+		Processes:         app.Processes,
+		LeftoverProcesses: app.LeftoverProcesses,
+		LogBuffer:         app.LogBuffer,
+		Redactions:        app.Redactions,
+	}
 }
 
 func (a *ApplicationResponseV1) Sanitize() {
 	a.Config.Env = nil
+	// Log sinks' headers often hold credentials
+	a.Config.LogSinks = nil
+}
+
+// StartResponseV1 represents the JSON response when an application is started or restarted
+type StartResponseV1 struct {
+	Status string `json:"status"`
+	// The dependencies which were started on the caller's behalf before the application
+	StartedDependencies []string `json:"started_dependencies,omitempty"`
+	// The dependents which were stopped and started again along with the application
+	RestartedDependents []string `json:"restarted_dependents,omitempty"`
+}
+
+// AppLogLineV1 represents a log line from one of several applications whose logs have been merged
+type AppLogLineV1 struct {
+	App string `json:"app"`
+	apps.LogLine
 }
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// HandleGetRuns returns the application's most recent runs, oldest first
+func (s *Server) HandleGetRuns(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	appName := vars["app_name"]
+
+	role := s.RequireAuthorization(w, r, AppViewer())
+	if !role.IsAllowed() {
+		return
+	}
+
+	runs, err := s.manager.GetRuns(appName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(runs); err != nil {
+		logrus.WithError(err).Error("Failed to encode runs response")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
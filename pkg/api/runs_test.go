@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/sierrasoftworks/tailon/pkg/apps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleGetRuns(t *testing.T) {
+	server, manager := SetupTestServer()
+
+	require.NoError(t, manager.StartApp(context.Background(), "test-app"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "test-app"))
+
+	req := httptest.NewRequest("GET", "/api/v1/apps/test-app/runs", nil)
+	req = mux.SetURLVars(req, map[string]string{"app_name": "test-app"})
+	recorder := httptest.NewRecorder()
+
+	server.HandleGetRuns(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var runs []apps.RunRecord
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &runs))
+	require.Len(t, runs, 1)
+	assert.Equal(t, uint64(1), runs[0].ID)
+	assert.Equal(t, apps.RunExited, runs[0].Outcome)
+
+	// The run's logs can be selected by its ID
+	req = httptest.NewRequest("GET", "/api/v1/apps/test-app/logs?run=1", nil)
+	req = mux.SetURLVars(req, map[string]string{"app_name": "test-app"})
+	recorder = httptest.NewRecorder()
+
+	server.HandleLogs(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var logs []apps.LogLine
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &logs))
+	assert.Contains(t, messages(logs), "hello")
+	for _, line := range logs {
+		assert.Equal(t, uint64(1), line.Run)
+	}
+}
+
+func TestHandleGetRunsNotFound(t *testing.T) {
+	server, _ := SetupTestServer()
+
+	req := httptest.NewRequest("GET", "/api/v1/apps/missing/runs", nil)
+	req = mux.SetURLVars(req, map[string]string{"app_name": "missing"})
+	recorder := httptest.NewRecorder()
+
+	server.HandleGetRuns(recorder, req)
+
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
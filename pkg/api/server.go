@@ -37,7 +37,10 @@ func (s *Server) Routes() *mux.Router {
 	api.HandleFunc("/apps/{app_name}/start", s.HandleStartApp).Methods("POST")
 	api.HandleFunc("/apps/{app_name}/stop", s.HandleStopApp).Methods("POST")
 	api.HandleFunc("/apps/{app_name}/restart", s.HandleRestartApp).Methods("POST")
+	api.HandleFunc("/apps/{app_name}/runs", s.HandleGetRuns).Methods("GET")
 	api.HandleFunc("/apps/{app_name}/logs", s.HandleLogs).Methods("GET")
+	api.HandleFunc("/apps/{app_name}/logs/export", s.HandleExportLogs).Methods("GET")
+	api.HandleFunc("/logs", s.HandleAggregatedLogs).Methods("GET")
 
 	// Add middleware
 	r.Use(s.userMiddleware.Handler) // Add user context middleware first
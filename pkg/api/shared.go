@@ -84,6 +84,30 @@ func SetupTestServer() (*Server, *apps.Manager) {
 			Path: "/bin/sh",
 			Args: []string{"-c", "echo 'test log'; sleep 0.1"},
 		},
+		{
+			Name:          "crash-app",
+			Path:          "/bin/sh",
+			Args:          []string{"-c", "echo 'crashing' >&2; exit 1"},
+			RestartPolicy: config.RestartAlways,
+			RestartBackoff: config.RestartBackoffConfig{
+				InitialDelay: config.Duration(10 * time.Millisecond),
+			},
+			CrashLoop: config.CrashLoopConfig{
+				MaxExits: 3,
+			},
+		},
+		{
+			Name:      "ready-app",
+			Path:      "/bin/sh",
+			Args:      []string{"-c", "sleep 0.1; echo 'listening on :8080'; sleep 10"},
+			ReadyWhen: "listening on :\\d+",
+		},
+		{
+			Name:      "worker-app",
+			Path:      "/bin/sleep",
+			Args:      []string{"10"},
+			DependsOn: []string{"ready-app"},
+		},
 	}
 
 	manager := apps.NewManager(configs)
@@ -0,0 +1,295 @@
+package apps
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+// LogSpan is a run of a log line's text which is displayed with the same style, as
+// described by the ANSI escape sequences the application wrote.
+type LogSpan struct {
+	Text string `json:"text"`
+	// The foreground and background colours, either one of the names of the 16 standard
+	// terminal colours (such as "red" or "bright-blue") or an RGB colour like "#ff8700"
+	Fg            string `json:"fg,omitempty"`
+	Bg            string `json:"bg,omitempty"`
+	Bold          bool   `json:"bold,omitempty"`
+	Dim           bool   `json:"dim,omitempty"`
+	Italic        bool   `json:"italic,omitempty"`
+	Underline     bool   `json:"underline,omitempty"`
+	Inverse       bool   `json:"inverse,omitempty"`
+	Strikethrough bool   `json:"strikethrough,omitempty"`
+}
+
+// styled returns true if the span has any style other than the terminal's default
+func (s LogSpan) styled() bool {
+	s.Text = ""
+	return s != LogSpan{}
+}
+
+// ansiColors are the names of the 16 standard terminal colours, by their palette index
+var ansiColors = [16]string{
+	"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white",
+	"bright-black", "bright-red", "bright-green", "bright-yellow", "bright-blue", "bright-magenta", "bright-cyan", "bright-white",
+}
+
+// ParseANSI removes the escape sequences and other control characters (except tabs) from
+// the text, returning the plain text along with the styled spans it is made up of. No
+// spans are returned if the text has no styling.
+func ParseANSI(text string) (string, []LogSpan) {
+	// Most lines have no control characters at all
+	if !hasControlCharacters(text) {
+		return text, nil
+	}
+
+	var (
+		plain   strings.Builder
+		current strings.Builder
+		spans   []LogSpan
+		style   LogSpan
+		styled  bool
+	)
+
+	flush := func() {
+		if current.Len() == 0 {
+			return
+		}
+
+		span := style
+		span.Text = current.String()
+		current.Reset()
+
+		// Adjacent spans with the same style are merged
+		if n := len(spans); n > 0 {
+			previous := spans[n-1]
+			previous.Text = span.Text
+			if previous == span {
+				spans[n-1].Text += span.Text
+				return
+			}
+		}
+
+		spans = append(spans, span)
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == 0x1b:
+			sequence, params, final := scanEscapeSequence(text[i:])
+			i += sequence
+			if final == 'm' {
+				flush()
+				style = applySGR(style, params)
+				styled = styled || style.styled()
+			}
+		case c < 0x20 && c != '\t', c == 0x7f:
+			i++
+		default:
+			plain.WriteByte(c)
+			current.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+
+	if !styled {
+		return plain.String(), nil
+	}
+
+	return plain.String(), spans
+}
+
+// hasControlCharacters returns true if the text has any characters which ParseANSI removes
+func hasControlCharacters(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if c := text[i]; (c < 0x20 && c != '\t') || c == 0x7f {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scanEscapeSequence returns the length of the escape sequence at the start of the text,
+// along with the parameters and final byte of CSI sequences (and 0 for other sequences).
+func scanEscapeSequence(text string) (int, string, byte) {
+	if len(text) < 2 {
+		return len(text), "", 0
+	}
+
+	switch text[1] {
+	case '[':
+		// CSI: parameter and intermediate bytes followed by a final byte
+		for i := 2; i < len(text); i++ {
+			if c := text[i]; c >= 0x40 && c <= 0x7e {
+				return i + 1, text[2:i], c
+			} else if c < 0x20 || c > 0x7e {
+				// Malformed, so only the introducer is removed
+				return i, "", 0
+			}
+		}
+
+		return len(text), "", 0
+	case ']', 'P', '_', '^':
+		// OSC and other strings, terminated by BEL or ST (ESC \)
+		for i := 2; i < len(text); i++ {
+			if text[i] == 0x07 {
+				return i + 1, "", 0
+			}
+
+			if text[i] == 0x1b && i+1 < len(text) && text[i+1] == '\\' {
+				return i + 2, "", 0
+			}
+		}
+
+		return len(text), "", 0
+	default:
+		// Two character sequences, possibly with intermediate bytes (such as ESC ( B)
+		i := 1
+		for i < len(text) && text[i] >= 0x20 && text[i] <= 0x2f {
+			i++
+		}
+
+		return min(i+1, len(text)), "", 0
+	}
+}
+
+// applySGR applies the Select Graphic Rendition parameters to the style
+func applySGR(style LogSpan, params string) LogSpan {
+	if params == "" {
+		return LogSpan{}
+	}
+
+	// Sequences with private parameters (such as ESC [>4;2m) don't change the style
+	if strings.ContainsAny(params[:1], "<=>?") {
+		return style
+	}
+
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		// Parameters may have sub-parameters separated by colons (such as 38:2::255:0:0)
+		parts := strings.Split(codes[i], ":")
+
+		code := 0
+		if parts[0] != "" {
+			var err error
+			if code, err = strconv.Atoi(parts[0]); err != nil {
+				continue
+			}
+		}
+
+		switch {
+		case code == 0:
+			style = LogSpan{}
+		case code == 1:
+			style.Bold = true
+		case code == 2:
+			style.Dim = true
+		case code == 3:
+			style.Italic = true
+		case code == 4:
+			style.Underline = true
+		case code == 7:
+			style.Inverse = true
+		case code == 9:
+			style.Strikethrough = true
+		case code == 22:
+			style.Bold, style.Dim = false, false
+		case code == 23:
+			style.Italic = false
+		case code == 24:
+			style.Underline = false
+		case code == 27:
+			style.Inverse = false
+		case code == 29:
+			style.Strikethrough = false
+		case code >= 30 && code <= 37:
+			style.Fg = ansiColors[code-30]
+		case code == 39:
+			style.Fg = ""
+		case code >= 40 && code <= 47:
+			style.Bg = ansiColors[code-40]
+		case code == 49:
+			style.Bg = ""
+		case code >= 90 && code <= 97:
+			style.Fg = ansiColors[code-90+8]
+		case code >= 100 && code <= 107:
+			style.Bg = ansiColors[code-100+8]
+		case code == 38 || code == 48:
+			var color string
+			if len(parts) > 1 {
+				// The colour space ID which may precede the RGB values is ignored
+				if parts[1] == "2" && len(parts) > 5 {
+					parts = append(parts[:2], parts[3:]...)
+				}
+				color, _ = extendedColor(parts[1:])
+			} else {
+				var used int
+				color, used = extendedColor(codes[i+1:])
+				i += used
+			}
+
+			if code == 38 {
+				style.Fg = color
+			} else {
+				style.Bg = color
+			}
+		}
+	}
+
+	return style
+}
+
+// extendedColor reads a 256 colour (5;n) or RGB (2;r;g;b) colour, returning it along with
+// the number of parameters it used.
+func extendedColor(params []string) (string, int) {
+	values := make([]int, 0, 4)
+	for _, param := range params {
+		value, err := strconv.Atoi(param)
+		if err != nil || value < 0 || value > 255 {
+			value = 0
+		}
+		values = append(values, value)
+	}
+
+	switch {
+	case len(values) >= 2 && values[0] == 5:
+		return paletteColor(values[1]), 2
+	case len(values) >= 4 && values[0] == 2:
+		return fmt.Sprintf("#%02x%02x%02x", values[1], values[2], values[3]), 4
+	default:
+		return "", len(values)
+	}
+}
+
+// paletteColor returns the colour from the 256 colour palette
+func paletteColor(index int) string {
+	switch {
+	case index < 16:
+		return ansiColors[index]
+	case index < 232:
+		// A 6x6x6 colour cube
+		levels := [6]int{0, 95, 135, 175, 215, 255}
+		index -= 16
+		return fmt.Sprintf("#%02x%02x%02x", levels[index/36], levels[index/6%6], levels[index%6])
+	default:
+		// A greyscale ramp
+		grey := 8 + (index-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", grey, grey, grey)
+	}
+}
+
+// applyANSIMode handles the escape sequences in the log line's message as configured for
+// the application.
+func applyANSIMode(mode config.ANSIMode, line *LogLine) {
+	switch mode {
+	case config.ANSIStrip:
+		line.Message, _ = ParseANSI(line.Message)
+	case config.ANSIConvert:
+		line.Message, line.Spans = ParseANSI(line.Message)
+	}
+}
@@ -0,0 +1,133 @@
+package apps
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+func TestParseANSI(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		plain string
+		spans []LogSpan
+	}{
+		{
+			name:  "plain text",
+			input: "hello\tworld",
+			plain: "hello\tworld",
+		},
+		{
+			name:  "colours",
+			input: "\x1b[1;31mERROR\x1b[0m something \x1b[32mgreen\x1b[m",
+			plain: "ERROR something green",
+			spans: []LogSpan{
+				{Text: "ERROR", Fg: "red", Bold: true},
+				{Text: " something "},
+				{Text: "green", Fg: "green"},
+			},
+		},
+		{
+			name:  "bright and background colours",
+			input: "\x1b[94;41mblue on red\x1b[39mdefault on red\x1b[49m",
+			plain: "blue on reddefault on red",
+			spans: []LogSpan{
+				{Text: "blue on red", Fg: "bright-blue", Bg: "red"},
+				{Text: "default on red", Bg: "red"},
+			},
+		},
+		{
+			name:  "256 and RGB colours",
+			input: "\x1b[38;5;208morange\x1b[38;2;1;2;3mrgb\x1b[48:2::255:0:0mred background\x1b[38;5;9mbright",
+			plain: "orangergbred backgroundbright",
+			spans: []LogSpan{
+				{Text: "orange", Fg: "#ff8700"},
+				{Text: "rgb", Fg: "#010203"},
+				{Text: "red background", Fg: "#010203", Bg: "#ff0000"},
+				{Text: "bright", Fg: "bright-red", Bg: "#ff0000"},
+			},
+		},
+		{
+			name:  "attributes",
+			input: "\x1b[3;4mitalic\x1b[23m underlined\x1b[24;9;2m struck\x1b[22;29;7m inverse",
+			plain: "italic underlined struck inverse",
+			spans: []LogSpan{
+				{Text: "italic", Italic: true, Underline: true},
+				{Text: " underlined", Underline: true},
+				{Text: " struck", Strikethrough: true, Dim: true},
+				{Text: " inverse", Inverse: true},
+			},
+		},
+		{
+			name:  "adjacent spans with the same style are merged",
+			input: "\x1b[31mred\x1b[31m still red",
+			plain: "red still red",
+			spans: []LogSpan{{Text: "red still red", Fg: "red"}},
+		},
+		{
+			name:  "other control sequences are removed",
+			input: "\x1b[2K\rprogress\x1b[?25l\x1b]0;title\x07 done\x1b(B\x08",
+			plain: "progress done",
+		},
+		{
+			name:  "resets alone aren't styling",
+			input: "\x1b[0mplain\x1b[m",
+			plain: "plain",
+		},
+		{
+			name:  "truncated sequence",
+			input: "text\x1b[31",
+			plain: "text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain, spans := ParseANSI(tt.input)
+			assert.Equal(t, tt.plain, plain)
+			assert.Equal(t, tt.spans, spans)
+		})
+	}
+}
+
+func TestCollectLogsANSI(t *testing.T) {
+	output := `printf '\033[31mred\033[0m text\n'`
+	manager := NewManager([]config.ApplicationConfig{
+		{Name: "preserve", Path: "/bin/sh", Args: []string{"-c", output}},
+		{Name: "strip", Path: "/bin/sh", Args: []string{"-c", output}, ANSI: config.ANSIStrip},
+		{Name: "convert", Path: "/bin/sh", Args: []string{"-c", output}, ANSI: config.ANSIConvert},
+	})
+
+	stdout := func(name string) LogLine {
+		require.NoError(t, manager.StartApp(context.Background(), name))
+		require.NoError(t, manager.WaitForExit(context.Background(), name))
+
+		logs, err := manager.GetLogs(name)
+		require.NoError(t, err)
+		for _, line := range logs {
+			if line.Source == "stdout" {
+				return line
+			}
+		}
+
+		t.Fatalf("%s didn't log anything to stdout", name)
+		return LogLine{}
+	}
+
+	line := stdout("preserve")
+	assert.Equal(t, "\x1b[31mred\x1b[0m text", line.Message)
+	assert.Nil(t, line.Spans)
+
+	line = stdout("strip")
+	assert.Equal(t, "red text", line.Message)
+	assert.Nil(t, line.Spans)
+
+	line = stdout("convert")
+	assert.Equal(t, "red text", line.Message)
+	assert.Equal(t, []LogSpan{{Text: "red", Fg: "red"}, {Text: " text"}}, line.Spans)
+}
@@ -0,0 +1,56 @@
+package apps
+
+import (
+	"context"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/userctx"
+)
+
+// Autostart starts every application which is configured to start automatically, or
+// whose restart policy is "always", waiting for each application's autostart delay first. Applications are started
+// on behalf of the System user, and any pending starts are abandoned if the
+// context is cancelled.
+func (m *Manager) Autostart(ctx context.Context) {
+	ctx = userctx.WithSystemUser(ctx)
+	logger := userctx.GetLoggerFromContext(ctx)
+
+	m.mux.RLock()
+	delays := make(map[string]time.Duration)
+	for name, app := range m.apps {
+		if app.Config.Autostart || app.Config.RestartPolicy.StartsWithTailon() {
+			delays[name] = app.Config.AutostartDelay.Duration()
+		}
+	}
+	m.mux.RUnlock()
+
+	for name, delay := range delays {
+		go func() {
+			if delay > 0 {
+				logger.WithField("app", name).WithField("delay", delay).Info("Waiting to autostart application")
+
+				timer := time.NewTimer(delay)
+				defer timer.Stop()
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-timer.C:
+				}
+			}
+
+			if _, err := m.startDependencies(ctx, name); err != nil {
+				logger.WithField("app", name).WithError(err).Error("Failed to autostart application")
+				return
+			}
+
+			// The application may already have been started as a dependency of another application
+			started, err := m.ensureStarted(ctx, name, "Started application")
+			if err != nil {
+				logger.WithField("app", name).WithError(err).Error("Failed to autostart application")
+			} else if !started {
+				logger.WithField("app", name).Debug("Application is already running, skipping autostart")
+			}
+		}()
+	}
+}
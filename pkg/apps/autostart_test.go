@@ -0,0 +1,126 @@
+package apps
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutostart(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:      "autostarted",
+			Path:      "/bin/sh",
+			Args:      []string{"-c", "sleep 10"},
+			Autostart: true,
+		},
+		{
+			Name:           "delayed",
+			Path:           "/bin/sh",
+			Args:           []string{"-c", "sleep 10"},
+			Autostart:      true,
+			AutostartDelay: config.Duration(200 * time.Millisecond),
+		},
+		{
+			Name: "manual",
+			Path: "/bin/sh",
+			Args: []string{"-c", "sleep 10"},
+		},
+	}
+
+	manager := NewManager(configs)
+	manager.Autostart(context.Background())
+
+	require.Eventually(t, func() bool {
+		app, _ := manager.GetApp("autostarted")
+		return app.State == StateRunning
+	}, time.Second, 10*time.Millisecond)
+
+	app, err := manager.GetApp("delayed")
+	require.NoError(t, err)
+	assert.Equal(t, StateNotRunning, app.State, "delayed applications should not start immediately")
+
+	require.Eventually(t, func() bool {
+		app, _ := manager.GetApp("delayed")
+		return app.State == StateRunning
+	}, time.Second, 10*time.Millisecond)
+
+	app, err = manager.GetApp("manual")
+	require.NoError(t, err)
+	assert.Equal(t, StateNotRunning, app.State)
+
+	// Autostarted applications are attributed to the system user
+	app, err = manager.GetApp("autostarted")
+	require.NoError(t, err)
+	require.NotNil(t, app.StateChangedBy)
+	assert.Equal(t, "$system$", app.StateChangedBy.ID)
+
+	logs, err := manager.GetLogs("autostarted")
+	require.NoError(t, err)
+	require.NotEmpty(t, logs)
+	assert.Equal(t, "System: Started application", logs[0].Message)
+
+	manager.ForceStopApp(context.Background(), "autostarted")
+	manager.ForceStopApp(context.Background(), "delayed")
+}
+
+func TestAutostartCancelled(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:           "delayed",
+			Path:           "/bin/sh",
+			Args:           []string{"-c", "sleep 10"},
+			Autostart:      true,
+			AutostartDelay: config.Duration(100 * time.Millisecond),
+		},
+	}
+
+	manager := NewManager(configs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	manager.Autostart(ctx)
+	cancel()
+
+	time.Sleep(200 * time.Millisecond)
+
+	app, err := manager.GetApp("delayed")
+	require.NoError(t, err)
+	assert.Equal(t, StateNotRunning, app.State)
+}
+
+func TestAutostartAlwaysRestartPolicy(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:          "always",
+			Path:          "/bin/sh",
+			Args:          []string{"-c", "sleep 10"},
+			RestartPolicy: config.RestartAlways,
+		},
+		{
+			Name:          "unless-stopped",
+			Path:          "/bin/sh",
+			Args:          []string{"-c", "sleep 10"},
+			RestartPolicy: config.RestartUnlessStopped,
+		},
+	}
+
+	manager := NewManager(configs)
+	defer manager.Shutdown(context.Background())
+
+	// Applications which should always be running are started with tailon, even without autostart
+	manager.Autostart(context.Background())
+
+	require.Eventually(t, func() bool {
+		app, _ := manager.GetApp("always")
+		return app.State == StateRunning
+	}, time.Second, 10*time.Millisecond)
+
+	time.Sleep(100 * time.Millisecond)
+	app, err := manager.GetApp("unless-stopped")
+	require.NoError(t, err)
+	assert.Equal(t, StateNotRunning, app.State)
+}
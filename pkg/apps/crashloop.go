@@ -0,0 +1,116 @@
+package apps
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultCrashLoopMaxExits = 5
+	defaultCrashLoopWindow   = time.Minute
+
+	// The number of recent exit codes and stderr lines attached to a failure
+	failureExitCodes  = 5
+	failureStderrTail = 20
+)
+
+// FailureInfo describes why an application was placed in the failed state
+type FailureInfo struct {
+	Reason     string    `json:"reason"`
+	FailedAt   time.Time `json:"failed_at"`
+	ExitCodes  []int     `json:"exit_codes"`
+	StderrTail []string  `json:"stderr_tail"`
+}
+
+type exitRecord struct {
+	at       time.Time
+	exitCode int
+}
+
+// recordExit tracks the application's exit so that crash loops can be detected.
+// The manager's lock must be held while calling it.
+func (a *Application) recordExit(at time.Time, exitCode int) {
+	a.recentExits = append(a.recentExits, exitRecord{at: at, exitCode: exitCode})
+
+	// Only keep as many exits as we could need for detection or reporting
+	keep := max(a.crashLoopMaxExits(), failureExitCodes)
+	if len(a.recentExits) > keep {
+		a.recentExits = a.recentExits[len(a.recentExits)-keep:]
+	}
+}
+
+func (a *Application) crashLoopMaxExits() int {
+	if a.Config.CrashLoop.MaxExits > 0 {
+		return a.Config.CrashLoop.MaxExits
+	}
+
+	return defaultCrashLoopMaxExits
+}
+
+func (a *Application) crashLoopWindow() time.Duration {
+	if a.Config.CrashLoop.Window > 0 {
+		return a.Config.CrashLoop.Window.Duration()
+	}
+
+	return defaultCrashLoopWindow
+}
+
+// isCrashLooping returns true if the application has exited too many times within
+// its crash loop window. The manager's lock must be held while calling it.
+func (a *Application) isCrashLooping(now time.Time) bool {
+	maxExits := a.crashLoopMaxExits()
+	if len(a.recentExits) < maxExits {
+		return false
+	}
+
+	oldest := a.recentExits[len(a.recentExits)-maxExits]
+	return now.Sub(oldest.at) <= a.crashLoopWindow()
+}
+
+// fail parks the application in the failed state, capturing diagnostic information
+// about its recent exits. The manager's lock must be held while calling it.
+func (m *Manager) fail(app *Application, reason string) {
+	now := time.Now()
+
+	exits := app.recentExits
+	if len(exits) > failureExitCodes {
+		exits = exits[len(exits)-failureExitCodes:]
+	}
+
+	exitCodes := make([]int, 0, len(exits))
+	for _, exit := range exits {
+		exitCodes = append(exitCodes, exit.exitCode)
+	}
+
+	app.cancelRestart()
+	app.State = StateFailed
+	app.StateChangedAt = &now
+	app.Failure = &FailureInfo{
+		Reason:     reason,
+		FailedAt:   now,
+		ExitCodes:  exitCodes,
+		StderrTail: app.tailLogs("stderr", failureStderrTail),
+	}
+
+	m.addAuditLog(app, app.StateChangedBy, fmt.Sprintf("Application failed: %s", reason))
+}
+
+// tailLogs returns the messages of the last n log lines from the given source
+func (a *Application) tailLogs(source string, n int) []string {
+	a.logMux.RLock()
+	defer a.logMux.RUnlock()
+
+	tail := make([]string, 0, n)
+	for i := a.logs.len() - 1; i >= 0 && len(tail) < n; i-- {
+		if line := a.logs.at(i); line.Source == source {
+			tail = append(tail, line.Message)
+		}
+	}
+
+	// Restore chronological order
+	for i, j := 0, len(tail)-1; i < j; i, j = i+1, j-1 {
+		tail[i], tail[j] = tail[j], tail[i]
+	}
+
+	return tail
+}
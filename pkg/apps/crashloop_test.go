@@ -0,0 +1,86 @@
+package apps
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrashLoopDetection(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:          "crash-loop",
+			Path:          "/bin/sh",
+			Args:          []string{"-c", "echo 'something went wrong' >&2; exit 7"},
+			RestartPolicy: config.RestartAlways,
+			RestartBackoff: config.RestartBackoffConfig{
+				InitialDelay: config.Duration(10 * time.Millisecond),
+				MaxDelay:     config.Duration(10 * time.Millisecond),
+			},
+			CrashLoop: config.CrashLoopConfig{
+				MaxExits: 3,
+				Window:   config.Duration(10 * time.Second),
+			},
+		},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "crash-loop"))
+
+	require.Eventually(t, func() bool {
+		app, _ := manager.GetApp("crash-loop")
+		return app.State == StateFailed
+	}, 2*time.Second, 10*time.Millisecond)
+
+	app, err := manager.GetApp("crash-loop")
+	require.NoError(t, err)
+	require.NotNil(t, app.Failure)
+	assert.Contains(t, app.Failure.Reason, "crash loop")
+	assert.Equal(t, []int{7, 7, 7}, app.Failure.ExitCodes)
+	assert.Contains(t, app.Failure.StderrTail, "something went wrong")
+	assert.Nil(t, app.NextRestartAt)
+
+	// A manual start clears the failure
+	require.NoError(t, manager.StartApp(context.Background(), "crash-loop"))
+	app, err = manager.GetApp("crash-loop")
+	require.NoError(t, err)
+	assert.Nil(t, app.Failure)
+}
+
+func TestIsCrashLooping(t *testing.T) {
+	app := &Application{
+		Config: config.ApplicationConfig{
+			CrashLoop: config.CrashLoopConfig{
+				MaxExits: 2,
+				Window:   config.Duration(time.Minute),
+			},
+		},
+	}
+
+	now := time.Now()
+	app.recordExit(now.Add(-2*time.Minute), 1)
+	app.recordExit(now, 1)
+	assert.False(t, app.isCrashLooping(now), "exits outside of the window should not count")
+
+	app.recordExit(now, 1)
+	assert.True(t, app.isCrashLooping(now))
+}
+
+func TestTailLogs(t *testing.T) {
+	app := &Application{logs: newLogBuffer(config.LogBufferConfig{})}
+	for _, line := range []LogLine{
+		{Source: "stderr", Message: "one"},
+		{Source: "stdout", Message: "ignored"},
+		{Source: "stderr", Message: "two"},
+		{Source: "stderr", Message: "three"},
+	} {
+		app.logs.push(line)
+	}
+
+	assert.Equal(t, []string{"two", "three"}, app.tailLogs("stderr", 2))
+	assert.Equal(t, []string{"one", "two", "three"}, app.tailLogs("stderr", 10))
+}
@@ -0,0 +1,409 @@
+package apps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/sierrasoftworks/tailon/pkg/userctx"
+)
+
+// dependencyOrder returns the application's direct and indirect dependencies in the
+// order in which they should be started.
+func (m *Manager) dependencyOrder(name string) ([]string, error) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	var order []string
+	visited := make(map[string]bool)
+	visiting := make(map[string]bool)
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		if visiting[name] {
+			return fmt.Errorf("dependency cycle detected: %s", strings.Join(append(path, name), " -> "))
+		}
+
+		if visited[name] {
+			return nil
+		}
+
+		app, exists := m.apps[name]
+		if !exists {
+			return fmt.Errorf("application %s not found", name)
+		}
+
+		visiting[name] = true
+		for _, dep := range app.Config.DependsOn {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		visiting[name] = false
+		visited[name] = true
+
+		order = append(order, name)
+		return nil
+	}
+
+	if err := visit(name, nil); err != nil {
+		return nil, err
+	}
+
+	// The application itself is always the last entry
+	return order[:len(order)-1], nil
+}
+
+// dependents returns the applications which directly or indirectly depend on the
+// application, in the order in which they should be stopped.
+func (m *Manager) dependents(name string) []string {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	depth := make(map[string]int)
+	var visit func(name string, d int)
+	visit = func(name string, d int) {
+		for other, app := range m.apps {
+			for _, dep := range app.Config.DependsOn {
+				// Depths are bounded by the number of applications in case of a cycle
+				if dep == name && depth[other] < d+1 && d < len(m.apps) {
+					depth[other] = d + 1
+					visit(other, d+1)
+				}
+			}
+		}
+	}
+	visit(name, 0)
+
+	return sortByDepth(depth)
+}
+
+// stopOrder returns the names of the given applications sorted so that applications are
+// stopped before the applications they depend on.
+func (m *Manager) stopOrder(names []string) []string {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	depths := make(map[string]int, len(names))
+	var depth func(name string, seen map[string]bool) int
+	depth = func(name string, seen map[string]bool) int {
+		app, exists := m.apps[name]
+		if !exists || seen[name] {
+			return 0
+		}
+
+		seen[name] = true
+		defer delete(seen, name)
+
+		d := 0
+		for _, dep := range app.Config.DependsOn {
+			d = max(d, depth(dep, seen)+1)
+		}
+
+		return d
+	}
+
+	for _, name := range names {
+		depths[name] = depth(name, make(map[string]bool))
+	}
+
+	return sortByDepth(depths)
+}
+
+// sortByDepth returns the names in the map ordered from the deepest to the shallowest,
+// breaking ties by name so that the order is stable.
+func sortByDepth(depths map[string]int) []string {
+	names := make([]string, 0, len(depths))
+	for name := range depths {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if depths[names[i]] != depths[names[j]] {
+			return depths[names[i]] > depths[names[j]]
+		}
+
+		return names[i] < names[j]
+	})
+
+	return names
+}
+
+// StartAppWithDependencies starts the application once all of its dependencies are running,
+// starting any which are not and waiting for each of them to become ready. It returns the
+// names of the dependencies which it started on the caller's behalf.
+func (m *Manager) StartAppWithDependencies(ctx context.Context, name string) ([]string, error) {
+	if err := m.canStart(name); err != nil {
+		return nil, err
+	}
+
+	started, err := m.startDependencies(ctx, name)
+	if err != nil {
+		return started, err
+	}
+
+	return started, m.startApp(ctx, name, "Started application")
+}
+
+// RestartApp stops the application, after stopping the applications which depend on it, and
+// then starts it again once its dependencies are running. The dependents which were stopped
+// are started again afterwards, before their own dependents. It returns the names of the
+// dependencies which were started and the dependents which were restarted on the caller's behalf.
+func (m *Manager) RestartApp(ctx context.Context, name string) ([]string, []string, error) {
+	if err := m.authorizeRestart(ctx, name); err != nil {
+		return nil, nil, err
+	}
+
+	stopped, err := m.stopWithDependents(ctx, name, false)
+	if err != nil {
+		var forbidden *ForbiddenError
+		if errors.As(err, &forbidden) {
+			return nil, nil, err
+		}
+
+		userctx.GetLoggerFromContext(ctx).WithField("app", name).WithError(err).Debug("Application was not running during restart")
+	}
+
+	// Wait for the application to stop completely, which is bounded by its stop timeout
+	if err := m.WaitForExit(ctx, name); err != nil {
+		return nil, nil, fmt.Errorf("failed to wait for application %s to stop: %w", name, err)
+	}
+
+	started, err := m.StartAppWithDependencies(ctx, name)
+	if err != nil {
+		return started, nil, err
+	}
+
+	restarted := []string{}
+	for i := len(stopped) - 1; i >= 0; i-- {
+		dependent := stopped[i]
+
+		deps, err := m.startDependencies(ctx, dependent)
+		started = appendMissing(started, deps...)
+		if err != nil {
+			return started, restarted, fmt.Errorf("failed to restart dependent %s: %w", dependent, err)
+		}
+
+		ok, err := m.ensureStarted(ctx, dependent, fmt.Sprintf("Restarted application (dependent of %s)", name))
+		if err != nil {
+			return started, restarted, fmt.Errorf("failed to restart dependent %s: %w", dependent, err)
+		}
+
+		if ok {
+			restarted = append(restarted, dependent)
+		}
+	}
+
+	return started, restarted, nil
+}
+
+// authorizeRestart checks, before anything is stopped, that the caller may operate every
+// application which restarting the application would stop or start on their behalf.
+func (m *Manager) authorizeRestart(ctx context.Context, name string) error {
+	m.mux.RLock()
+	app, exists := m.apps[name]
+	stoppable := exists && app.isStoppable()
+	m.mux.RUnlock()
+
+	// Dependents are only stopped, and so restarted, if the application is running
+	restarting := []string{name}
+	if stoppable {
+		restarting = append(restarting, m.filterApps(m.dependents(name), (*Application).isStoppable)...)
+	}
+
+	affected := slices.Clone(restarting[1:])
+	for _, restarted := range restarting {
+		deps, err := m.dependencyOrder(restarted)
+		if err != nil {
+			return err
+		}
+
+		for _, dep := range m.filterApps(deps, func(a *Application) bool { return !a.IsRunning() }) {
+			if !slices.Contains(restarting, dep) {
+				affected = appendMissing(affected, dep)
+			}
+		}
+	}
+
+	return authorize(ctx, affected)
+}
+
+// startDependencies starts the application's dependencies which are not already running, and
+// waits for each of them to become ready, returning the names of the ones which it started.
+func (m *Manager) startDependencies(ctx context.Context, name string) ([]string, error) {
+	deps, err := m.dependencyOrder(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nothing is started unless the caller may start every dependency which needs it
+	if err := authorize(ctx, m.filterApps(deps, func(app *Application) bool { return !app.IsRunning() })); err != nil {
+		return nil, err
+	}
+
+	started := []string{}
+	for _, dep := range deps {
+		ok, err := m.ensureStarted(ctx, dep, fmt.Sprintf("Started application (dependency of %s)", name))
+		if err != nil {
+			return started, fmt.Errorf("failed to start dependency %s: %w", dep, err)
+		}
+
+		if ok {
+			started = append(started, dep)
+		}
+
+		if err := m.WaitReady(ctx, dep); err != nil {
+			return started, fmt.Errorf("dependency %s did not become ready: %w", dep, err)
+		}
+	}
+
+	return started, nil
+}
+
+// ensureStarted starts the application on the caller's behalf unless it is already running or
+// starting, such as when another application which shares a dependency started it first. It
+// returns whether the application was started.
+func (m *Manager) ensureStarted(ctx context.Context, name string, auditMsg string) (bool, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if app, exists := m.apps[name]; exists && app.IsRunning() {
+		return false, nil
+	}
+
+	if err := authorize(ctx, []string{name}); err != nil {
+		return false, err
+	}
+
+	app, err := m.startable(name)
+	if err != nil {
+		return false, err
+	}
+
+	return true, m.start(ctx, app, auditMsg)
+}
+
+// stopWithDependents stops the applications which depend on the application, waiting for
+// them to exit, before stopping the application itself. It returns the names of the
+// dependents which it stopped, in the order in which they were stopped.
+func (m *Manager) stopWithDependents(ctx context.Context, name string, force bool) ([]string, error) {
+	m.mux.RLock()
+	app, exists := m.apps[name]
+	stoppable := exists && app.isStoppable()
+	m.mux.RUnlock()
+
+	// Leave it to stopApp to explain why the application can't be stopped
+	if !stoppable {
+		return nil, m.stopApp(ctx, name, force)
+	}
+
+	dependents := m.dependents(name)
+
+	// Nothing is stopped unless the caller may stop every dependent which needs it
+	if err := authorize(ctx, m.filterApps(dependents, (*Application).isStoppable)); err != nil {
+		return nil, err
+	}
+
+	stopped := []string{}
+	for _, dependent := range dependents {
+		m.mux.RLock()
+		running := m.apps[dependent].isStoppable()
+		m.mux.RUnlock()
+
+		if running {
+			if err := authorize(ctx, []string{dependent}); err != nil {
+				return stopped, err
+			}
+
+			if err := m.stopApp(ctx, dependent, force); err != nil {
+				return stopped, fmt.Errorf("failed to stop dependent %s: %w", dependent, err)
+			}
+
+			stopped = append(stopped, dependent)
+		}
+
+		if err := m.WaitForExit(ctx, dependent); err != nil {
+			return stopped, fmt.Errorf("failed to wait for dependent %s to stop: %w", dependent, err)
+		}
+	}
+
+	return stopped, m.stopApp(ctx, name, force)
+}
+
+// isStoppable returns true if the application is running or waiting to be restarted.
+// The manager's lock must be held while calling it.
+func (a *Application) isStoppable() bool {
+	return a.IsRunning() || a.State == StateBackoff
+}
+
+// filterApps returns the named applications which match the predicate
+func (m *Manager) filterApps(names []string, match func(*Application) bool) []string {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	matched := []string{}
+	for _, name := range names {
+		if app, exists := m.apps[name]; exists && match(app) {
+			matched = append(matched, name)
+		}
+	}
+
+	return matched
+}
+
+// appendMissing appends the names which the slice doesn't already contain
+func appendMissing(names []string, more ...string) []string {
+	for _, name := range more {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// ForbiddenError is returned when starting or stopping an application would start or stop
+// other applications, such as its dependencies or dependents, which the caller may not operate.
+type ForbiddenError struct {
+	Applications []string
+}
+
+func (e *ForbiddenError) Error() string {
+	return fmt.Sprintf("not permitted to start or stop applications: %s", strings.Join(e.Applications, ", "))
+}
+
+type contextKey string
+
+const authorizerContextKey contextKey = "authorizer"
+
+// WithAuthorizer returns a context in which the applications which are started or stopped on the
+// caller's behalf, such as the dependencies and dependents of the application which they asked
+// to start or stop, must be allowed by the authorizer. It is called while the manager is locked.
+func WithAuthorizer(ctx context.Context, authorized func(name string) bool) context.Context {
+	return context.WithValue(ctx, authorizerContextKey, authorized)
+}
+
+// authorize returns a ForbiddenError listing the applications which the context's authorizer
+// doesn't allow the caller to operate, if any.
+func authorize(ctx context.Context, names []string) error {
+	authorized, ok := ctx.Value(authorizerContextKey).(func(string) bool)
+	if !ok {
+		return nil
+	}
+
+	var forbidden []string
+	for _, name := range names {
+		if !authorized(name) {
+			forbidden = append(forbidden, name)
+		}
+	}
+
+	if len(forbidden) > 0 {
+		return &ForbiddenError{Applications: forbidden}
+	}
+
+	return nil
+}
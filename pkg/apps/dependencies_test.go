@@ -0,0 +1,224 @@
+package apps
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dependencyTestConfigs() []config.ApplicationConfig {
+	return []config.ApplicationConfig{
+		{
+			Name:       "worker",
+			Path:       "/bin/sh",
+			Args:       []string{"-c", "sleep 10 & wait"},
+			StopSignal: "SIGTERM",
+			DependsOn:  []string{"queue", "database"},
+		},
+		{
+			Name:       "queue",
+			Path:       "/bin/sh",
+			Args:       []string{"-c", "sleep 0.1; echo 'queue ready'; sleep 10 & wait"},
+			StopSignal: "SIGTERM",
+			ReadyWhen:  "ready",
+			DependsOn:  []string{"database"},
+		},
+		{
+			Name:       "database",
+			Path:       "/bin/sh",
+			Args:       []string{"-c", "sleep 10 & wait"},
+			StopSignal: "SIGTERM",
+		},
+		{
+			Name: "standalone",
+			Path: "/bin/sleep",
+			Args: []string{"10"},
+		},
+	}
+}
+
+func TestDependencyOrder(t *testing.T) {
+	manager := NewManager(dependencyTestConfigs())
+
+	order, err := manager.dependencyOrder("worker")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"database", "queue"}, order)
+
+	order, err = manager.dependencyOrder("standalone")
+	require.NoError(t, err)
+	assert.Empty(t, order)
+
+	assert.Equal(t, []string{"worker", "queue"}, manager.dependents("database"))
+	assert.Equal(t, []string{"worker", "queue", "database", "standalone"}, manager.stopOrder([]string{"database", "standalone", "queue", "worker"}))
+}
+
+func TestDependencyOrderCycle(t *testing.T) {
+	manager := NewManager([]config.ApplicationConfig{
+		{Name: "a", Path: "/bin/true", DependsOn: []string{"b"}},
+		{Name: "b", Path: "/bin/true", DependsOn: []string{"a"}},
+	})
+
+	_, err := manager.dependencyOrder("a")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dependency cycle detected")
+
+	assert.Error(t, manager.StartApp(context.Background(), "a"))
+}
+
+func TestStartAppWithDependencies(t *testing.T) {
+	manager := NewManager(dependencyTestConfigs())
+	defer manager.Shutdown(context.Background())
+
+	// Dependencies which are already running are not started again
+	require.NoError(t, manager.StartApp(context.Background(), "database"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	started, err := manager.StartAppWithDependencies(ctx, "worker")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"queue"}, started)
+
+	queue, _ := manager.GetApp("queue")
+	assert.Equal(t, StateRunning, queue.State, "the queue should have become ready before the worker started")
+	assert.True(t, findAuditLog(t, manager, "queue", "Started application (dependency of worker)"))
+
+	worker, _ := manager.GetApp("worker")
+	assert.True(t, worker.IsRunning())
+}
+
+func TestStopAppStopsDependents(t *testing.T) {
+	manager := NewManager(dependencyTestConfigs())
+	defer manager.Shutdown(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, manager.StartApp(ctx, "worker"))
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, manager.StopApp(ctx, "database"))
+
+	// The dependents have exited before the database was asked to stop
+	for _, name := range []string{"worker", "queue"} {
+		app, _ := manager.GetApp(name)
+		assert.Equal(t, StateNotRunning, app.State, "%s should have stopped before its dependency", name)
+	}
+
+	require.NoError(t, manager.WaitForExit(ctx, "database"))
+	database, _ := manager.GetApp("database")
+	assert.Equal(t, StateNotRunning, database.State)
+}
+
+func TestStartAppSharedDependencyConcurrently(t *testing.T) {
+	manager := NewManager(dependencyTestConfigs())
+	defer manager.Shutdown(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Both applications start the database, which only one of them may actually start
+	errs := make(chan error, 2)
+	for _, name := range []string{"worker", "queue"} {
+		go func() {
+			_, err := manager.StartAppWithDependencies(ctx, name)
+			errs <- err
+		}()
+	}
+
+	for range 2 {
+		err := <-errs
+		if err != nil {
+			// The worker may find that the queue was started as its own application
+			assert.Contains(t, err.Error(), "application queue is already running")
+		}
+	}
+
+	database, _ := manager.GetApp("database")
+	assert.True(t, database.IsRunning())
+
+	runs, err := manager.GetRuns("database")
+	require.NoError(t, err)
+	assert.Len(t, runs, 1, "the database should only have been started once")
+}
+
+func TestRestartAppRestartsDependents(t *testing.T) {
+	manager := NewManager(dependencyTestConfigs())
+	defer manager.Shutdown(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, manager.StartApp(ctx, "worker"))
+	require.NoError(t, manager.WaitReady(ctx, "worker"))
+
+	started, restarted, err := manager.RestartApp(ctx, "database")
+	require.NoError(t, err)
+	assert.Empty(t, started)
+	assert.Equal(t, []string{"queue", "worker"}, restarted)
+
+	for _, name := range []string{"database", "queue", "worker"} {
+		runs, err := manager.GetRuns(name)
+		require.NoError(t, err)
+		require.Len(t, runs, 2, "%s should have been restarted", name)
+		assert.Equal(t, RunStopped, runs[0].Outcome)
+		assert.Equal(t, RunActive, runs[1].Outcome)
+	}
+
+	assert.True(t, findAuditLog(t, manager, "worker", "Restarted application (dependent of database)"))
+
+	// Dependents which weren't running aren't started
+	require.NoError(t, manager.StopApp(ctx, "worker"))
+	require.NoError(t, manager.WaitForExit(ctx, "worker"))
+
+	_, restarted, err = manager.RestartApp(ctx, "database")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"queue"}, restarted)
+
+	worker, _ := manager.GetApp("worker")
+	assert.Equal(t, StateNotRunning, worker.State)
+}
+
+func TestDependencyAuthorization(t *testing.T) {
+	manager := NewManager(dependencyTestConfigs())
+	defer manager.Shutdown(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The caller may only operate the worker and the database
+	restricted := WithAuthorizer(ctx, func(name string) bool {
+		return name == "worker" || name == "database"
+	})
+
+	_, err := manager.StartAppWithDependencies(restricted, "worker")
+	var forbidden *ForbiddenError
+	require.ErrorAs(t, err, &forbidden)
+	assert.Equal(t, []string{"queue"}, forbidden.Applications)
+
+	// Nothing is started unless everything can be
+	database, _ := manager.GetApp("database")
+	assert.Equal(t, StateNotRunning, database.State)
+
+	require.NoError(t, manager.StartApp(ctx, "worker"))
+	require.NoError(t, manager.WaitReady(ctx, "worker"))
+
+	// Nothing is stopped unless everything can be
+	require.ErrorAs(t, manager.StopApp(restricted, "database"), &forbidden)
+	assert.Equal(t, []string{"queue"}, forbidden.Applications)
+
+	_, _, err = manager.RestartApp(restricted, "database")
+	require.ErrorAs(t, err, &forbidden)
+
+	for _, name := range []string{"database", "queue", "worker"} {
+		app, _ := manager.GetApp(name)
+		assert.True(t, app.IsRunning(), "%s should still be running", name)
+	}
+
+	// The application which the caller asked to operate isn't checked by the manager
+	require.NoError(t, manager.StopApp(restricted, "worker"))
+}
@@ -0,0 +1,107 @@
+package apps
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/sierrasoftworks/tailon/pkg/logsink"
+)
+
+// logSinkFlushTimeout is how long Shutdown waits for forwarded log lines to be delivered
+const logSinkFlushTimeout = 5 * time.Second
+
+// ConfigureLogSinks starts forwarding every application's logs to the global sinks, as well
+// as to any sinks configured for the application itself. The node name identifies this
+// instance of tailon in the forwarded records.
+func (m *Manager) ConfigureLogSinks(node string, global []config.LogSinkConfig) error {
+	var shared []*logsink.Forwarder
+	for _, cfg := range global {
+		forwarder, err := logsink.NewForwarder(cfg)
+		if err != nil {
+			m.closeForwarders(shared)
+			return fmt.Errorf("failed to create log sink: %w", err)
+		}
+		shared = append(shared, forwarder)
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.sinks = shared
+
+	for name, app := range m.apps {
+		forwarders := append([]*logsink.Forwarder(nil), shared...)
+		for _, cfg := range app.Config.LogSinks {
+			forwarder, err := logsink.NewForwarder(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to create log sink for application %s: %w", name, err)
+			}
+
+			app.sinks = append(app.sinks, forwarder)
+			forwarders = append(forwarders, forwarder)
+		}
+
+		app.logMux.Lock()
+		app.forwarders = forwarders
+		app.node = node
+		app.logMux.Unlock()
+	}
+
+	return nil
+}
+
+// forward sends the log line to the application's sinks. The application's log lock must
+// be held while calling it, so that lines are forwarded in order.
+func (a *Application) forward(line LogLine) {
+	if len(a.forwarders) == 0 {
+		return
+	}
+
+	record := logsink.Record{
+		App:       a.Config.Name,
+		Node:      a.node,
+		Source:    line.Source,
+		Seq:       line.Seq,
+		Timestamp: line.Timestamp,
+		Message:   line.Message,
+		Level:     line.Level,
+		Fields:    line.Fields,
+	}
+
+	for _, forwarder := range a.forwarders {
+		forwarder.Forward(record)
+	}
+}
+
+// closeLogSinks delivers any log lines which are waiting to be forwarded and closes the sinks
+func (m *Manager) closeLogSinks() {
+	m.mux.Lock()
+	forwarders := m.sinks
+	m.sinks = nil
+	for _, app := range m.apps {
+		forwarders = append(forwarders, app.sinks...)
+		app.sinks = nil
+
+		app.logMux.Lock()
+		app.forwarders = nil
+		app.logMux.Unlock()
+	}
+	m.mux.Unlock()
+
+	m.closeForwarders(forwarders)
+}
+
+func (m *Manager) closeForwarders(forwarders []*logsink.Forwarder) {
+	ctx, cancel := context.WithTimeout(context.Background(), logSinkFlushTimeout)
+	defer cancel()
+
+	for _, forwarder := range forwarders {
+		if err := forwarder.Close(ctx); err != nil {
+			logrus.WithError(err).Warn("Failed to close log sink")
+		}
+	}
+}
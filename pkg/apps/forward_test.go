@@ -0,0 +1,109 @@
+package apps
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/sierrasoftworks/tailon/pkg/logsink"
+)
+
+// collectingServer accepts records from an HTTP log sink
+type collectingServer struct {
+	*httptest.Server
+	mux     sync.Mutex
+	records []logsink.Record
+}
+
+func newCollectingServer(t *testing.T) *collectingServer {
+	s := &collectingServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var record logsink.Record
+			if err := json.Unmarshal(scanner.Bytes(), &record); err == nil {
+				s.mux.Lock()
+				s.records = append(s.records, record)
+				s.mux.Unlock()
+			}
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *collectingServer) received() []logsink.Record {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return append([]logsink.Record(nil), s.records...)
+}
+
+func TestForwardLogs(t *testing.T) {
+	global := newCollectingServer(t)
+	local := newCollectingServer(t)
+
+	manager := NewManager([]config.ApplicationConfig{
+		{
+			Name: "forwarded",
+			Path: "/bin/sh",
+			Args: []string{"-c", "echo hello; echo oops >&2"},
+			LogSinks: []config.LogSinkConfig{
+				{HTTP: &config.HTTPSinkConfig{URL: local.URL}},
+			},
+		},
+		{
+			Name: "other",
+			Path: "/bin/echo",
+			Args: []string{"unrelated"},
+		},
+	})
+
+	require.NoError(t, manager.ConfigureLogSinks("node-1", []config.LogSinkConfig{
+		{HTTP: &config.HTTPSinkConfig{URL: global.URL}},
+	}))
+
+	require.NoError(t, manager.StartApp(context.Background(), "forwarded"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "forwarded"))
+	require.NoError(t, manager.StartApp(context.Background(), "other"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "other"))
+
+	// Shutting down delivers anything which is still waiting to be sent
+	require.NoError(t, manager.Shutdown(context.Background()))
+
+	find := func(records []logsink.Record, app, source, message string) *logsink.Record {
+		for _, record := range records {
+			if record.App == app && record.Source == source && record.Message == message {
+				return &record
+			}
+		}
+		return nil
+	}
+
+	records := global.received()
+	stdout := find(records, "forwarded", "stdout", "hello")
+	require.NotNil(t, stdout, "stdout should be forwarded to the global sink")
+	assert.Equal(t, "node-1", stdout.Node)
+	assert.NotZero(t, stdout.Seq)
+	assert.NotNil(t, find(records, "forwarded", "stderr", "oops"), "stderr should be forwarded to the global sink")
+	assert.NotNil(t, find(records, "other", "stdout", "unrelated"), "every application should be forwarded to the global sink")
+
+	records = local.received()
+	assert.NotNil(t, find(records, "forwarded", "stdout", "hello"), "stdout should be forwarded to the application's sink")
+	assert.Nil(t, find(records, "other", "stdout", "unrelated"), "other applications shouldn't be forwarded to the application's sink")
+
+	for _, record := range records {
+		if record.Source == "audit" {
+			return
+		}
+	}
+	t.Error("audit lines should be forwarded too")
+}
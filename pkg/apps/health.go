@@ -0,0 +1,253 @@
+package apps
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/sierrasoftworks/tailon/pkg/userctx"
+)
+
+const (
+	defaultHealthCheckInterval = 30 * time.Second
+	defaultHealthCheckTimeout  = 5 * time.Second
+	defaultHealthCheckRetries  = 3
+
+	// The maximum amount of a health check's output which is retained
+	maxHealthCheckOutput = 1024
+)
+
+// HealthStatus describes the outcome of an application's health checks
+type HealthStatus string
+
+const (
+	// HealthStarting indicates that the application has not yet passed a health check
+	HealthStarting HealthStatus = "starting"
+	// HealthHealthy indicates that the application's most recent health check passed
+	HealthHealthy HealthStatus = "healthy"
+	// HealthUnhealthy indicates that the application failed too many consecutive health checks
+	HealthUnhealthy HealthStatus = "unhealthy"
+)
+
+// HealthState holds the results of an application's health checks
+type HealthState struct {
+	Status              HealthStatus `json:"status"`
+	LastCheckAt         *time.Time   `json:"last_check_at,omitempty"`
+	LastOutput          string       `json:"last_output,omitempty"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+}
+
+func healthCheckInterval(check *config.HealthCheckConfig) time.Duration {
+	if check.Interval > 0 {
+		return check.Interval.Duration()
+	}
+
+	return defaultHealthCheckInterval
+}
+
+func healthCheckTimeout(check *config.HealthCheckConfig) time.Duration {
+	if check.Timeout > 0 {
+		return check.Timeout.Duration()
+	}
+
+	return defaultHealthCheckTimeout
+}
+
+func healthCheckRetries(check *config.HealthCheckConfig) int {
+	if check.Retries > 0 {
+		return check.Retries
+	}
+
+	return defaultHealthCheckRetries
+}
+
+// monitorHealth periodically runs the application's health check until the given run
+// of the application exits.
+func (m *Manager) monitorHealth(app *Application, run uint64, exited <-chan struct{}, startedAt time.Time) {
+	cfg := app.Config
+	check := cfg.HealthCheck
+
+	ticker := time.NewTicker(healthCheckInterval(check))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-exited:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout(check))
+		go func() {
+			// Abandon the check as soon as the application exits
+			select {
+			case <-exited:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		output, err := runHealthCheck(ctx, cfg)
+		cancel()
+
+		m.recordHealth(app, run, startedAt, output, err)
+	}
+}
+
+// recordHealth applies the result of a health check to the application, restarting it
+// if it has become unhealthy and is configured to do so.
+func (m *Manager) recordHealth(app *Application, run uint64, startedAt time.Time, output string, checkErr error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if app.run != run || app.State != StateRunning || app.Health == nil {
+		return
+	}
+
+	check := app.Config.HealthCheck
+	logger := logrus.WithField("app", app.Config.Name)
+	user := userctx.System()
+
+	now := time.Now()
+	if checkErr != nil {
+		output = strings.TrimSpace(strings.Join([]string{output, checkErr.Error()}, "\n"))
+	}
+
+	// Health checks run with the application's environment, so may reveal its secrets too
+	output, redacted := app.redactor.redact(output)
+	app.redacted.Add(uint64(redacted))
+
+	// The health state is replaced rather than modified so that snapshots handed
+	// out to callers are never changed underneath them.
+	previous := app.Health
+	health := &HealthState{
+		Status:              previous.Status,
+		LastCheckAt:         &now,
+		LastOutput:          truncateOutput(output),
+		ConsecutiveFailures: previous.ConsecutiveFailures,
+	}
+	app.Health = health
+
+	if checkErr == nil {
+		health.Status = HealthHealthy
+		health.ConsecutiveFailures = 0
+
+		if previous.Status == HealthUnhealthy {
+			m.addAuditLog(app, user, "Application is healthy again")
+			logger.Info("Application is healthy again")
+		}
+
+		return
+	}
+
+	// Failures while the application is still initializing don't count against it
+	if previous.Status == HealthStarting && now.Sub(startedAt) < check.StartPeriod.Duration() {
+		return
+	}
+
+	health.ConsecutiveFailures++
+	if health.ConsecutiveFailures < healthCheckRetries(check) || previous.Status == HealthUnhealthy {
+		return
+	}
+
+	health.Status = HealthUnhealthy
+	m.addAuditLog(app, user, fmt.Sprintf("Application is unhealthy after %d failed health checks: %s", health.ConsecutiveFailures, health.LastOutput))
+	logger.WithError(checkErr).WithField("failures", health.ConsecutiveFailures).Warn("Application is unhealthy")
+
+	if check.RestartOnUnhealthy && !m.shuttingDown {
+		// The application is started again by its monitor once it has exited
+		app.restartRequested = true
+		m.beginStop(app, user, logger, false, "Restarting unhealthy application")
+	}
+}
+
+// relaunch starts an application again immediately after it was stopped by tailon
+// because it was unhealthy. The manager's lock must be held while calling it.
+func (m *Manager) relaunch(app *Application, logger *logrus.Entry) {
+	user := userctx.System()
+	if err := m.launch(app, user, logger, "Restarted application (unhealthy)"); err != nil {
+		logger.WithField("app", app.Config.Name).WithError(err).Warn("Failed to restart unhealthy application")
+		m.addAuditLog(app, user, fmt.Sprintf("Failed to restart application: %v", err))
+	}
+}
+
+// runHealthCheck performs a single health check, returning its output and an error if
+// the check failed.
+func runHealthCheck(ctx context.Context, cfg config.ApplicationConfig) (string, error) {
+	check := cfg.HealthCheck
+
+	switch {
+	case check.HTTP != nil:
+		return runHTTPHealthCheck(ctx, check.HTTP)
+	case check.TCP != nil:
+		return runTCPHealthCheck(ctx, check.TCP)
+	case check.Exec != nil:
+		return runExecHealthCheck(ctx, check.Exec, cfg)
+	default:
+		return "", fmt.Errorf("no health check configured")
+	}
+}
+
+func runHTTPHealthCheck(ctx context.Context, check *config.HTTPHealthCheck) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, check.URL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create health check request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxHealthCheckOutput))
+	output := fmt.Sprintf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+
+	if check.ExpectedStatus != 0 {
+		if resp.StatusCode != check.ExpectedStatus {
+			return output, fmt.Errorf("expected status %d but received %d", check.ExpectedStatus, resp.StatusCode)
+		}
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return output, fmt.Errorf("received unhealthy status %d", resp.StatusCode)
+	}
+
+	return output, nil
+}
+
+func runTCPHealthCheck(ctx context.Context, check *config.TCPHealthCheck) (string, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", check.Address)
+	if err != nil {
+		return "", err
+	}
+	conn.Close()
+
+	return fmt.Sprintf("Connected to %s", check.Address), nil
+}
+
+func runExecHealthCheck(ctx context.Context, check *config.ExecHealthCheck, cfg config.ApplicationConfig) (string, error) {
+	cmd := exec.CommandContext(ctx, check.Command[0], check.Command[1:]...)
+	cmd.Env = append(os.Environ(), cfg.Env...)
+	cmd.Dir = cfg.WorkingDir
+	cmd.WaitDelay = outputDrainTimeout
+
+	output, err := cmd.CombinedOutput()
+	return strings.TrimSpace(string(output)), err
+}
+
+func truncateOutput(output string) string {
+	if len(output) <= maxHealthCheckOutput {
+		return output
+	}
+
+	return strings.ToValidUTF8(output[:maxHealthCheckOutput], "") + "..."
+}
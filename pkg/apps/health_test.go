@@ -0,0 +1,184 @@
+package apps
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunHealthCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthy" {
+			w.Write([]byte("ok"))
+			return
+		}
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	tests := []struct {
+		name    string
+		check   config.HealthCheckConfig
+		healthy bool
+	}{
+		{"http healthy", config.HealthCheckConfig{HTTP: &config.HTTPHealthCheck{URL: server.URL + "/healthy"}}, true},
+		{"http unhealthy", config.HealthCheckConfig{HTTP: &config.HTTPHealthCheck{URL: server.URL + "/unhealthy"}}, false},
+		{"http expected status", config.HealthCheckConfig{HTTP: &config.HTTPHealthCheck{URL: server.URL + "/unhealthy", ExpectedStatus: 503}}, true},
+		{"tcp healthy", config.HealthCheckConfig{TCP: &config.TCPHealthCheck{Address: listener.Addr().String()}}, true},
+		{"exec healthy", config.HealthCheckConfig{Exec: &config.ExecHealthCheck{Command: []string{"/bin/sh", "-c", "test \"$CHECK\" = yes"}}}, true},
+		{"exec unhealthy", config.HealthCheckConfig{Exec: &config.ExecHealthCheck{Command: []string{"/bin/sh", "-c", "exit 1"}}}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := config.ApplicationConfig{
+				Name:        "test",
+				Env:         []string{"CHECK=yes"},
+				HealthCheck: &test.check,
+			}
+
+			_, err := runHealthCheck(context.Background(), cfg)
+			if test.healthy {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestHealthCheckStatus(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name: "healthy",
+			Path: "/bin/sleep",
+			Args: []string{"10"},
+			HealthCheck: &config.HealthCheckConfig{
+				Exec:     &config.ExecHealthCheck{Command: []string{"true"}},
+				Interval: config.Duration(20 * time.Millisecond),
+			},
+		},
+		{
+			Name: "unhealthy",
+			Path: "/bin/sleep",
+			Args: []string{"10"},
+			HealthCheck: &config.HealthCheckConfig{
+				Exec:     &config.ExecHealthCheck{Command: []string{"/bin/sh", "-c", "echo broken; exit 1"}},
+				Interval: config.Duration(20 * time.Millisecond),
+				Retries:  2,
+			},
+		},
+	}
+
+	manager := NewManager(configs)
+	defer manager.Shutdown(context.Background())
+
+	require.NoError(t, manager.StartApp(context.Background(), "healthy"))
+	require.NoError(t, manager.StartApp(context.Background(), "unhealthy"))
+
+	app, _ := manager.GetApp("healthy")
+	require.NotNil(t, app.Health)
+	assert.Equal(t, HealthStarting, app.Health.Status)
+
+	require.Eventually(t, func() bool {
+		app, _ := manager.GetApp("healthy")
+		return app.Health != nil && app.Health.Status == HealthHealthy
+	}, time.Second, 10*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		app, _ := manager.GetApp("unhealthy")
+		return app.Health != nil && app.Health.Status == HealthUnhealthy
+	}, time.Second, 10*time.Millisecond)
+
+	app, _ = manager.GetApp("unhealthy")
+	assert.Equal(t, StateRunning, app.State, "unhealthy applications are not restarted unless configured")
+	assert.GreaterOrEqual(t, app.Health.ConsecutiveFailures, 2)
+	assert.Contains(t, app.Health.LastOutput, "broken")
+	assert.NotNil(t, app.Health.LastCheckAt)
+	assert.True(t, findAuditLog(t, manager, "unhealthy", "Application is unhealthy after 2 failed health checks"))
+}
+
+func TestHealthCheckStartPeriod(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name: "slow-starter",
+			Path: "/bin/sleep",
+			Args: []string{"10"},
+			HealthCheck: &config.HealthCheckConfig{
+				Exec:        &config.ExecHealthCheck{Command: []string{"false"}},
+				Interval:    config.Duration(20 * time.Millisecond),
+				Retries:     1,
+				StartPeriod: config.Duration(time.Hour),
+			},
+		},
+	}
+
+	manager := NewManager(configs)
+	defer manager.Shutdown(context.Background())
+
+	require.NoError(t, manager.StartApp(context.Background(), "slow-starter"))
+
+	require.Eventually(t, func() bool {
+		app, _ := manager.GetApp("slow-starter")
+		return app.Health.LastCheckAt != nil
+	}, time.Second, 10*time.Millisecond)
+
+	time.Sleep(100 * time.Millisecond)
+
+	app, _ := manager.GetApp("slow-starter")
+	assert.Equal(t, HealthStarting, app.Health.Status)
+	assert.Equal(t, 0, app.Health.ConsecutiveFailures)
+}
+
+func TestHealthCheckRestartOnUnhealthy(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name: "restarting",
+			Path: "/bin/sh",
+			// The application only becomes healthy once it has been restarted
+			Args:       []string{"-c", "if [ -e started ]; then touch healthy; fi; touch started; exec sleep 10"},
+			WorkingDir: t.TempDir(),
+			HealthCheck: &config.HealthCheckConfig{
+				Exec:               &config.ExecHealthCheck{Command: []string{"test", "-e", "healthy"}},
+				Interval:           config.Duration(20 * time.Millisecond),
+				Retries:            1,
+				RestartOnUnhealthy: true,
+			},
+		},
+	}
+
+	manager := NewManager(configs)
+	defer manager.Shutdown(context.Background())
+
+	require.NoError(t, manager.StartApp(context.Background(), "restarting"))
+	app, _ := manager.GetApp("restarting")
+	firstPID := app.PID
+
+	require.Eventually(t, func() bool {
+		app, _ := manager.GetApp("restarting")
+		return app.State == StateRunning && app.PID != firstPID && app.Health.Status == HealthHealthy
+	}, 2*time.Second, 10*time.Millisecond)
+
+	assert.True(t, findAuditLog(t, manager, "restarting", "Restarting unhealthy application"))
+	assert.True(t, findAuditLog(t, manager, "restarting", "Restarted application (unhealthy)"))
+
+	// A user's stop cancels the health check's restart
+	require.NoError(t, manager.StopApp(context.Background(), "restarting"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "restarting"))
+
+	app, _ = manager.GetApp("restarting")
+	assert.Equal(t, StateNotRunning, app.State)
+	assert.Nil(t, app.Health)
+}
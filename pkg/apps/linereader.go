@@ -0,0 +1,94 @@
+package apps
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultMaxLogLineLength is the length, in bytes, beyond which an application's output
+// lines are split into several log lines unless configured otherwise.
+const defaultMaxLogLineLength = 64 * 1024
+
+// outputLine is a line, or part of a line, of an application's output
+type outputLine struct {
+	text string
+	// Whether the line continues the previous one, which was too long to be logged whole
+	continued bool
+	// Whether the line was too long and continues in the next one
+	split bool
+}
+
+// lineReader splits an application's output into lines, breaking up any which are too
+// long so that the output is always drained, and replacing invalid UTF-8.
+type lineReader struct {
+	reader *bufio.Reader
+	// Bytes of an incomplete character at the end of the last split line
+	carry      []byte
+	continuing bool
+}
+
+func newLineReader(reader io.Reader, maxLength int) *lineReader {
+	if maxLength <= 0 {
+		maxLength = defaultMaxLogLineLength
+	}
+
+	return &lineReader{reader: bufio.NewReaderSize(reader, maxLength)}
+}
+
+// next returns the next line of output, or an error (io.EOF once the output has ended)
+func (r *lineReader) next() (outputLine, error) {
+	data, isPrefix, err := r.reader.ReadLine()
+	if err != nil {
+		if len(r.carry) > 0 {
+			// The output ended part way through a character
+			line := r.line(r.carry, false)
+			r.carry = nil
+			return line, nil
+		}
+
+		return outputLine{}, err
+	}
+
+	if len(r.carry) > 0 {
+		data = append(r.carry, data...)
+		r.carry = nil
+	}
+
+	// Avoid splitting a character across two lines
+	if isPrefix {
+		if cut := incompleteRune(data); cut < len(data) {
+			r.carry = append([]byte(nil), data[cut:]...)
+			data = data[:cut]
+		}
+	}
+
+	return r.line(data, isPrefix), nil
+}
+
+func (r *lineReader) line(data []byte, split bool) outputLine {
+	line := outputLine{
+		text:      strings.ToValidUTF8(string(data), "\uFFFD"),
+		continued: r.continuing,
+		split:     split,
+	}
+
+	r.continuing = split
+	return line
+}
+
+// incompleteRune returns the index at which an incomplete UTF-8 encoded character at the
+// end of data starts, or len(data) if there isn't one.
+func incompleteRune(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return i
+			}
+			break
+		}
+	}
+
+	return len(data)
+}
@@ -0,0 +1,106 @@
+package apps
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+func readOutputLines(t *testing.T, reader *lineReader) []outputLine {
+	t.Helper()
+
+	var lines []outputLine
+	for {
+		line, err := reader.next()
+		if err == io.EOF {
+			return lines
+		}
+		require.NoError(t, err)
+		lines = append(lines, line)
+	}
+}
+
+func TestLineReader(t *testing.T) {
+	reader := newLineReader(strings.NewReader("first\r\nsecond\n\nlast without newline"), 0)
+
+	assert.Equal(t, []outputLine{
+		{text: "first"},
+		{text: "second"},
+		{text: ""},
+		{text: "last without newline"},
+	}, readOutputLines(t, reader))
+}
+
+func TestLineReaderSplitsLongLines(t *testing.T) {
+	reader := newLineReader(strings.NewReader(strings.Repeat("a", 40)+"\nshort\n"), 16)
+
+	assert.Equal(t, []outputLine{
+		{text: strings.Repeat("a", 16), split: true},
+		{text: strings.Repeat("a", 16), continued: true, split: true},
+		{text: strings.Repeat("a", 8), continued: true},
+		{text: "short"},
+	}, readOutputLines(t, reader))
+}
+
+func TestLineReaderUTF8(t *testing.T) {
+	// A multi-byte character which straddles the split is kept whole
+	reader := newLineReader(strings.NewReader(strings.Repeat("a", 15)+"é and more\n"), 16)
+	lines := readOutputLines(t, reader)
+	require.Len(t, lines, 2)
+	assert.Equal(t, strings.Repeat("a", 15), lines[0].text)
+	assert.Equal(t, "é and more", lines[1].text)
+
+	// Invalid UTF-8 is replaced
+	reader = newLineReader(strings.NewReader("binary \xff\xfe data\n"), 0)
+	assert.Equal(t, []outputLine{{text: "binary � data"}}, readOutputLines(t, reader))
+
+	// Output which ends part way through a split character is still delivered
+	reader = newLineReader(strings.NewReader(strings.Repeat("a", 15)+"\xc3"), 16)
+	assert.Equal(t, []outputLine{
+		{text: strings.Repeat("a", 15), split: true},
+		{text: "�", continued: true},
+	}, readOutputLines(t, reader))
+}
+
+func TestCollectLogsLongLines(t *testing.T) {
+	manager := NewManager([]config.ApplicationConfig{
+		{
+			Name:             "verbose",
+			Path:             "/bin/sh",
+			Args:             []string{"-c", "head -c 5000 /dev/zero | tr '\\0' x; echo; echo after"},
+			MaxLogLineLength: 1024,
+		},
+	})
+
+	require.NoError(t, manager.StartApp(context.Background(), "verbose"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "verbose"))
+
+	logs, err := manager.GetLogs("verbose")
+	require.NoError(t, err)
+
+	var output []LogLine
+	for _, line := range logs {
+		if line.Source == "stdout" {
+			output = append(output, line)
+		}
+	}
+
+	// The whole line is kept, split into chunks, and the output after it is still read
+	require.Len(t, output, 6)
+	var joined strings.Builder
+	for i, line := range output[:5] {
+		assert.Equal(t, i > 0, line.Continued)
+		joined.WriteString(line.Message)
+	}
+	assert.Equal(t, strings.Repeat("x", 5000), joined.String())
+	assert.Equal(t, "after", output[5].Message)
+	assert.False(t, output[5].Continued)
+
+	assert.True(t, findAuditLog(t, manager, "verbose", "Split a line from stdout which was longer than 1024 bytes"))
+}
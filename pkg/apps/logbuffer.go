@@ -0,0 +1,122 @@
+package apps
+
+import "github.com/sierrasoftworks/tailon/pkg/config"
+
+// LogBufferUsage describes how much of an application's in-memory log buffer is in use
+type LogBufferUsage struct {
+	Lines    int   `json:"lines"`
+	Bytes    int64 `json:"bytes"`
+	MaxLines int   `json:"max_lines"`
+	// The maximum size of the buffered messages, or 0 if only the number of lines is limited
+	MaxBytes int64 `json:"max_bytes,omitempty"`
+}
+
+// logBuffer is a ring buffer of an application's most recent log lines, bounded by both
+// the number of lines and the total size of their messages. Its storage grows as lines
+// are added, so quiet applications never allocate their full capacity.
+type logBuffer struct {
+	lines    []LogLine
+	start    int
+	count    int
+	bytes    int64
+	maxLines int
+	maxBytes int64
+}
+
+func newLogBuffer(cfg config.LogBufferConfig) *logBuffer {
+	maxLines := cfg.MaxLines
+	if maxLines <= 0 {
+		maxLines = maxLogLines
+	}
+
+	return &logBuffer{
+		maxLines: maxLines,
+		maxBytes: cfg.MaxBytes,
+	}
+}
+
+func logLineSize(line LogLine) int64 {
+	size := len(line.Message)
+	for key, value := range line.Fields {
+		size += len(key) + len(value)
+	}
+
+	for _, span := range line.Spans {
+		size += len(span.Text)
+	}
+
+	return int64(size)
+}
+
+// push appends a line to the buffer, evicting the oldest lines to make room for it
+func (b *logBuffer) push(line LogLine) {
+	if b.count == b.maxLines {
+		b.evict()
+	}
+
+	if b.count == len(b.lines) {
+		b.grow()
+	}
+
+	b.lines[(b.start+b.count)%len(b.lines)] = line
+	b.count++
+	b.bytes += logLineSize(line)
+
+	// The newest line is always kept, even if it is larger than the buffer
+	for b.maxBytes > 0 && b.bytes > b.maxBytes && b.count > 1 {
+		b.evict()
+	}
+}
+
+// grow increases the buffer's storage, keeping the lines in order
+func (b *logBuffer) grow() {
+	size := min(max(2*len(b.lines), 16), b.maxLines)
+	lines := make([]LogLine, size)
+	b.copyTo(lines)
+
+	b.lines = lines
+	b.start = 0
+}
+
+// evict removes the oldest line from the buffer
+func (b *logBuffer) evict() {
+	b.bytes -= logLineSize(b.lines[b.start])
+	b.lines[b.start] = LogLine{}
+	b.start = (b.start + 1) % len(b.lines)
+	b.count--
+}
+
+// copyTo copies the buffered lines, oldest first, into dst which must be large enough to hold them
+func (b *logBuffer) copyTo(dst []LogLine) {
+	if b.count == 0 {
+		return
+	}
+
+	n := copy(dst, b.lines[b.start:min(b.start+b.count, len(b.lines))])
+	copy(dst[n:b.count], b.lines[:b.count-n])
+}
+
+// snapshot returns a copy of the buffered lines, oldest first
+func (b *logBuffer) snapshot() []LogLine {
+	lines := make([]LogLine, b.count)
+	b.copyTo(lines)
+	return lines
+}
+
+// at returns the i-th oldest line in the buffer
+func (b *logBuffer) at(i int) LogLine {
+	return b.lines[(b.start+i)%len(b.lines)]
+}
+
+func (b *logBuffer) len() int {
+	return b.count
+}
+
+func (b *logBuffer) usage() LogBufferUsage {
+	return LogBufferUsage{
+		Lines:    b.count,
+		Bytes:    b.bytes,
+		MaxLines: b.maxLines,
+		MaxBytes: b.maxBytes,
+	}
+}
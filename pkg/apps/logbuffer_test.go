@@ -0,0 +1,74 @@
+package apps
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func messages(lines []LogLine) []string {
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		result = append(result, line.Message)
+	}
+
+	return result
+}
+
+func TestLogBufferMaxLines(t *testing.T) {
+	buffer := newLogBuffer(config.LogBufferConfig{MaxLines: 3})
+
+	assert.Empty(t, buffer.snapshot())
+
+	for i := range 5 {
+		buffer.push(LogLine{Message: fmt.Sprintf("%d", i)})
+	}
+
+	assert.Equal(t, []string{"2", "3", "4"}, messages(buffer.snapshot()))
+	assert.Equal(t, LogBufferUsage{Lines: 3, Bytes: 3, MaxLines: 3}, buffer.usage())
+	assert.Equal(t, "2", buffer.at(0).Message)
+	assert.Equal(t, "4", buffer.at(2).Message)
+}
+
+func TestLogBufferDefaults(t *testing.T) {
+	buffer := newLogBuffer(config.LogBufferConfig{})
+	assert.Equal(t, maxLogLines, buffer.usage().MaxLines)
+
+	buffer.push(LogLine{Message: "hello"})
+	assert.Less(t, len(buffer.lines), maxLogLines, "storage should only grow as lines are added")
+}
+
+func TestLogBufferMaxBytes(t *testing.T) {
+	buffer := newLogBuffer(config.LogBufferConfig{MaxLines: 100, MaxBytes: 10})
+
+	buffer.push(LogLine{Message: "aaaa"})
+	buffer.push(LogLine{Message: "bbbb"})
+	buffer.push(LogLine{Message: "cccc"})
+
+	assert.Equal(t, []string{"bbbb", "cccc"}, messages(buffer.snapshot()))
+	assert.Equal(t, int64(8), buffer.usage().Bytes)
+
+	// A line which is larger than the buffer replaces everything else
+	buffer.push(LogLine{Message: strings.Repeat("x", 20)})
+	assert.Equal(t, []string{strings.Repeat("x", 20)}, messages(buffer.snapshot()))
+	assert.Equal(t, int64(20), buffer.usage().Bytes)
+}
+
+func TestLogBufferGrowsAfterWrapping(t *testing.T) {
+	buffer := newLogBuffer(config.LogBufferConfig{MaxLines: 100, MaxBytes: 40})
+
+	// Evicting the large line by size moves the start of the ring before its storage is full
+	buffer.push(LogLine{Message: strings.Repeat("x", 30)})
+	expected := []string{}
+	for i := range 26 {
+		message := fmt.Sprintf("%c", 'a'+i)
+		buffer.push(LogLine{Message: message})
+		expected = append(expected, message)
+	}
+
+	assert.Equal(t, expected, messages(buffer.snapshot()))
+	assert.Equal(t, LogBufferUsage{Lines: 26, Bytes: 26, MaxLines: 100, MaxBytes: 40}, buffer.usage())
+}
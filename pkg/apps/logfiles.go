@@ -0,0 +1,338 @@
+package apps
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// logFilePollInterval is how often log files are checked for new lines, and their
+// patterns for new files.
+const logFilePollInterval = 250 * time.Millisecond
+
+// logFileTailer follows the log files written by an application, adding their lines to the
+// application's logs with the file's path as their source. Files which exist when tailing
+// starts are followed from their end, while files which appear later are read from the start.
+type logFileTailer struct {
+	manager  *Manager
+	app      string
+	patterns []string
+	interval time.Duration
+
+	mux       sync.Mutex
+	followers map[string]*fileFollower
+	// Paths which are ignored because they were renamed from a file which was already read
+	ignored map[string]struct{}
+	// Files which have already been read, but may yet reappear under another name when rotated
+	retired []os.FileInfo
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newLogFileTailer(m *Manager, app *Application) *logFileTailer {
+	patterns := make([]string, 0, len(app.Config.LogFiles))
+	for _, pattern := range app.Config.LogFiles {
+		if !filepath.IsAbs(pattern) && app.Config.WorkingDir != "" {
+			pattern = filepath.Join(app.Config.WorkingDir, pattern)
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	return &logFileTailer{
+		manager:   m,
+		app:       app.Config.Name,
+		patterns:  patterns,
+		interval:  logFilePollInterval,
+		followers: make(map[string]*fileFollower),
+		ignored:   make(map[string]struct{}),
+		stop:      make(chan struct{}),
+	}
+}
+
+// start begins following the log files in the background
+func (t *logFileTailer) start() {
+	t.scan(true)
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ticker.C:
+				t.scan(false)
+			}
+		}
+	}()
+}
+
+// close stops following the log files, once any lines which have been read are logged
+func (t *logFileTailer) close() {
+	close(t.stop)
+	t.wg.Wait()
+}
+
+// scan looks for log files which aren't being followed yet
+func (t *logFileTailer) scan(initial bool) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	matches := make(map[string]os.FileInfo)
+	for _, pattern := range t.patterns {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			continue
+		}
+
+		for _, path := range paths {
+			if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+				matches[path] = info
+			}
+		}
+	}
+
+	// Forget about files which can no longer be found under any name
+	retired := t.retired[:0]
+	for _, info := range t.retired {
+		for _, match := range matches {
+			if os.SameFile(info, match) {
+				retired = append(retired, info)
+				break
+			}
+		}
+	}
+	t.retired = retired
+
+	for path := range t.ignored {
+		if _, exists := matches[path]; !exists {
+			delete(t.ignored, path)
+		}
+	}
+
+	for path, info := range matches {
+		if _, exists := t.followers[path]; exists {
+			continue
+		}
+
+		if _, exists := t.ignored[path]; exists {
+			continue
+		}
+
+		if t.alreadyRead(info) {
+			t.ignored[path] = struct{}{}
+			continue
+		}
+
+		t.follow(path, initial)
+	}
+}
+
+// alreadyRead returns true if the file is, or was, being followed under another name.
+// The tailer's lock must be held while calling it.
+func (t *logFileTailer) alreadyRead(info os.FileInfo) bool {
+	for _, follower := range t.followers {
+		if follower.isFollowing(info) {
+			return true
+		}
+	}
+
+	for _, retired := range t.retired {
+		if os.SameFile(info, retired) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// follow starts following the file, from its end if it existed before tailing started.
+// The tailer's lock must be held while calling it.
+func (t *logFileTailer) follow(path string, fromEnd bool) {
+	follower, err := openFileFollower(path, fromEnd, t.interval, t.stop)
+	if err != nil {
+		logrus.WithField("app", t.app).WithField("file", path).WithError(err).Warn("Failed to open log file")
+		return
+	}
+
+	follower.retire = t.retire
+	t.followers[path] = follower
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		t.manager.mux.RLock()
+		app := t.manager.apps[t.app]
+		t.manager.mux.RUnlock()
+
+		t.manager.collectLogs(t.app, follower, path, nil, newLogParser(app.Config))
+
+		t.mux.Lock()
+		delete(t.followers, path)
+		t.mux.Unlock()
+
+		follower.close()
+	}()
+}
+
+// retire records that a file has been read, so that it isn't read again if it was rotated
+func (t *logFileTailer) retire(info os.FileInfo) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.retired = append(t.retired, info)
+}
+
+// fileFollower reads a log file as it is written, like tail -F. Once it reaches the end of
+// the file it waits for more to be written, reopening the path if the file is rotated and
+// starting again from the beginning if it is truncated. Reads return io.EOF once the file
+// has been removed without being replaced, or the follower is stopped.
+type fileFollower struct {
+	path     string
+	interval time.Duration
+	stop     <-chan struct{}
+	// Called with each file which the follower has finished reading
+	retire func(os.FileInfo)
+
+	mux    sync.Mutex
+	file   *os.File
+	info   os.FileInfo
+	offset int64
+}
+
+func openFileFollower(path string, fromEnd bool, interval time.Duration, stop <-chan struct{}) (*fileFollower, error) {
+	f := &fileFollower{path: path, interval: interval, stop: stop}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	if fromEnd {
+		offset, err := f.file.Seek(0, io.SeekEnd)
+		if err != nil {
+			f.file.Close()
+			return nil, err
+		}
+		f.offset = offset
+	}
+
+	return f, nil
+}
+
+// open opens the file at the follower's path, to be read from the beginning
+func (f *fileFollower) open() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.mux.Lock()
+	f.file, f.info, f.offset = file, info, 0
+	f.mux.Unlock()
+	return nil
+}
+
+// isFollowing returns true if the follower is currently reading the file
+func (f *fileFollower) isFollowing(info os.FileInfo) bool {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	return os.SameFile(f.info, info)
+}
+
+func (f *fileFollower) Read(p []byte) (int, error) {
+	for {
+		n, err := f.file.Read(p)
+		if n > 0 {
+			f.offset += int64(n)
+			return n, nil
+		}
+
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+
+		// We've caught up with the file, so check whether it has been replaced or truncated
+		info, err := os.Stat(f.path)
+		switch {
+		case os.IsNotExist(err):
+			// The file may have been rotated, in which case it will be picked up again once
+			// it has been recreated
+			return 0, io.EOF
+		case err != nil:
+			return 0, err
+		case !os.SameFile(info, f.info):
+			f.file.Close()
+			if f.retire != nil {
+				f.retire(f.info)
+			}
+
+			if err := f.open(); err != nil {
+				return 0, err
+			}
+			continue
+		case info.Size() < f.offset:
+			if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+				return 0, err
+			}
+			f.offset = 0
+			continue
+		}
+
+		select {
+		case <-f.stop:
+			return 0, io.EOF
+		case <-time.After(f.interval):
+		}
+	}
+}
+
+func (f *fileFollower) close() {
+	f.file.Close()
+	if f.retire != nil {
+		f.retire(f.info)
+	}
+}
+
+// startLogFileTailers starts following the log files of every application which has them
+func (m *Manager) startLogFileTailers() {
+	for _, app := range m.apps {
+		if len(app.Config.LogFiles) > 0 {
+			app.tailer = newLogFileTailer(m, app)
+			app.tailer.start()
+		}
+	}
+}
+
+// closeLogFileTailers stops following every application's log files
+func (m *Manager) closeLogFileTailers() {
+	// The tailers log the last of their lines as they close, which requires the lock
+	m.mux.Lock()
+	var tailers []*logFileTailer
+	for _, app := range m.apps {
+		if app.tailer != nil {
+			tailers = append(tailers, app.tailer)
+			app.tailer = nil
+		}
+	}
+	m.mux.Unlock()
+
+	for _, tailer := range tailers {
+		tailer.close()
+	}
+}
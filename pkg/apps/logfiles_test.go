@@ -0,0 +1,126 @@
+package apps
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+func appendFile(t *testing.T, path string, content string) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	defer file.Close()
+
+	_, err = file.WriteString(content)
+	require.NoError(t, err)
+}
+
+// fileLogs returns the messages which were read from the log file
+func fileLogs(t *testing.T, manager *Manager, name string, path string) []string {
+	logs, err := manager.GetLogs(name)
+	require.NoError(t, err)
+
+	messages := []string{}
+	for _, line := range logs {
+		if line.Source == path {
+			messages = append(messages, line.Message)
+		}
+	}
+	return messages
+}
+
+func waitForFileLogs(t *testing.T, manager *Manager, name string, path string, expected ...string) {
+	require.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual(expected, fileLogs(t, manager, name, path))
+	}, 5*time.Second, 20*time.Millisecond, "expected %q but got %q", expected, fileLogs(t, manager, name, path))
+}
+
+func TestLogFilesFollowed(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "written before tailon started\n")
+
+	manager := NewManager([]config.ApplicationConfig{
+		{Name: "files", Path: "/bin/true", LogFiles: []string{path}},
+	})
+	defer manager.Shutdown(context.Background())
+
+	// Only lines which are written once the file is being followed are logged
+	appendFile(t, path, "first\nsecond\n")
+	waitForFileLogs(t, manager, "files", path, "first", "second")
+
+	// Partial lines are logged once they have been completed
+	appendFile(t, path, "third ")
+	appendFile(t, path, "line\n")
+	waitForFileLogs(t, manager, "files", path, "first", "second", "third line")
+}
+
+func TestLogFilesRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "")
+
+	manager := NewManager([]config.ApplicationConfig{
+		{Name: "files", Path: "/bin/true", LogFiles: []string{filepath.Join(dir, "*.log*")}},
+	})
+	defer manager.Shutdown(context.Background())
+
+	appendFile(t, path, "before rotation\n")
+	waitForFileLogs(t, manager, "files", path, "before rotation")
+
+	// The rotated file matches the pattern too, but it shouldn't be read again
+	require.NoError(t, os.Rename(path, path+".1"))
+	appendFile(t, path, "after rotation\n")
+	waitForFileLogs(t, manager, "files", path, "before rotation", "after rotation")
+
+	time.Sleep(3 * logFilePollInterval)
+	assert.Empty(t, fileLogs(t, manager, "files", path+".1"))
+}
+
+func TestLogFilesTruncation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "")
+
+	manager := NewManager([]config.ApplicationConfig{
+		{Name: "files", Path: "/bin/true", LogFiles: []string{path}},
+	})
+	defer manager.Shutdown(context.Background())
+
+	appendFile(t, path, "a long line before truncation\n")
+	waitForFileLogs(t, manager, "files", path, "a long line before truncation")
+
+	require.NoError(t, os.Truncate(path, 0))
+	time.Sleep(2 * logFilePollInterval)
+	appendFile(t, path, "after\n")
+	waitForFileLogs(t, manager, "files", path, "a long line before truncation", "after")
+}
+
+func TestLogFilesGlob(t *testing.T) {
+	dir := t.TempDir()
+
+	manager := NewManager([]config.ApplicationConfig{
+		{Name: "files", Path: "/bin/true", WorkingDir: dir, LogFiles: []string{"logs/*.log"}},
+	})
+	defer manager.Shutdown(context.Background())
+
+	// Files which appear once tailon is running are read from the start, with relative
+	// patterns resolved against the application's working directory
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "logs"), 0o755))
+	path := filepath.Join(dir, "logs", "worker.log")
+	appendFile(t, path, "hello\n")
+	waitForFileLogs(t, manager, "files", path, "hello")
+
+	// Other files are ignored
+	other := filepath.Join(dir, "logs", "worker.txt")
+	appendFile(t, other, "ignored\n")
+	time.Sleep(3 * logFilePollInterval)
+	assert.Empty(t, fileLogs(t, manager, "files", other))
+}
@@ -0,0 +1,233 @@
+package apps
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+// The names commonly given to structured log lines' fields, which are used when the
+// application's configuration doesn't name them.
+var (
+	defaultLevelKeys     = []string{"level", "lvl", "severity"}
+	defaultMessageKeys   = []string{"msg", "message"}
+	defaultTimestampKeys = []string{"time", "ts", "timestamp"}
+)
+
+// logParser extracts the level, message, timestamp and other fields from an application's
+// structured log lines.
+type logParser struct {
+	format        config.LogFormat
+	levelKeys     []string
+	messageKeys   []string
+	timestampKeys []string
+}
+
+// newLogParser returns a parser for the application's log format, or nil if its logs are plain text
+func newLogParser(cfg config.ApplicationConfig) *logParser {
+	if cfg.LogFormat != config.LogFormatJSON && cfg.LogFormat != config.LogFormatLogfmt {
+		return nil
+	}
+
+	keys := func(name string, defaults []string) []string {
+		if name != "" {
+			return []string{name}
+		}
+		return defaults
+	}
+
+	return &logParser{
+		format:        cfg.LogFormat,
+		levelKeys:     keys(cfg.LogFields.Level, defaultLevelKeys),
+		messageKeys:   keys(cfg.LogFields.Message, defaultMessageKeys),
+		timestampKeys: keys(cfg.LogFields.Timestamp, defaultTimestampKeys),
+	}
+}
+
+// parse fills in the log line's structured fields from its message. Lines which are not in
+// the expected format are left as they are.
+func (p *logParser) parse(line *LogLine) {
+	if p == nil {
+		return
+	}
+
+	var fields map[string]string
+	var ok bool
+	switch p.format {
+	case config.LogFormatJSON:
+		fields, ok = parseJSONFields(line.Message)
+	case config.LogFormatLogfmt:
+		fields, ok = parseLogfmtFields(line.Message)
+	}
+
+	if !ok {
+		return
+	}
+
+	if level, ok := takeField(fields, p.levelKeys); ok {
+		line.Level = strings.ToLower(level)
+	}
+
+	if message, ok := takeField(fields, p.messageKeys); ok {
+		line.Message = message
+	}
+
+	for _, key := range p.timestampKeys {
+		if value, exists := fields[key]; exists {
+			if timestamp, ok := parseLogTimestamp(value); ok {
+				line.Timestamp = timestamp
+				delete(fields, key)
+			}
+			break
+		}
+	}
+
+	if len(fields) > 0 {
+		line.Fields = fields
+	}
+}
+
+// takeField removes and returns the value of the first of the keys which is present
+func takeField(fields map[string]string, keys []string) (string, bool) {
+	for _, key := range keys {
+		if value, exists := fields[key]; exists {
+			delete(fields, key)
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// parseLogTimestamp parses an RFC 3339 timestamp, or a Unix timestamp in seconds or milliseconds
+func parseLogTimestamp(value string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, true
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n <= 0 {
+		return time.Time{}, false
+	}
+
+	// Timestamps in seconds won't reach 1e11 for a few thousand years
+	if n >= 1e11 {
+		n /= 1000
+	}
+
+	seconds, fraction := math.Modf(n)
+	return time.Unix(int64(seconds), int64(fraction*1e9)), true
+}
+
+// parseJSONFields parses a JSON object, representing values which aren't strings using their JSON encoding
+func parseJSONFields(message string) (map[string]string, bool) {
+	trimmed := strings.TrimSpace(message)
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil, false
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(trimmed), &values); err != nil {
+		return nil, false
+	}
+
+	fields := make(map[string]string, len(values))
+	for key, value := range values {
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			fields[key] = s
+			continue
+		}
+
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, value); err == nil {
+			fields[key] = compacted.String()
+		} else {
+			fields[key] = string(value)
+		}
+	}
+
+	return fields, true
+}
+
+// parseLogfmtFields parses a line of key=value pairs, where values may be double quoted.
+// Keys without a value are given an empty one. Lines without any key=value pairs are not
+// considered to be logfmt.
+func parseLogfmtFields(message string) (map[string]string, bool) {
+	fields := make(map[string]string)
+	pairs := 0
+
+	rest := strings.TrimSpace(message)
+	for rest != "" {
+		end := strings.IndexFunc(rest, func(r rune) bool { return r == '=' || unicode.IsSpace(r) })
+		if end == 0 {
+			// A value without a key
+			return nil, false
+		}
+
+		if end < 0 {
+			fields[rest] = ""
+			break
+		}
+
+		key := rest[:end]
+		rest = rest[end:]
+
+		if rest[0] != '=' {
+			fields[key] = ""
+			rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+			continue
+		}
+
+		rest = rest[1:]
+		pairs++
+
+		if strings.HasPrefix(rest, `"`) {
+			value, remaining, ok := unquoteLogfmt(rest)
+			if !ok {
+				return nil, false
+			}
+
+			fields[key] = value
+			rest = remaining
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+
+			fields[key] = rest[:end]
+			rest = rest[end:]
+		}
+
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+	}
+
+	return fields, pairs > 0
+}
+
+// unquoteLogfmt reads a double quoted value from the start of s, returning it along with
+// the remainder of s.
+func unquoteLogfmt(s string) (string, string, bool) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			value, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", false
+			}
+
+			return value, s[i+1:], true
+		}
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,123 @@
+package apps
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+func TestLogParserText(t *testing.T) {
+	assert.Nil(t, newLogParser(config.ApplicationConfig{}))
+	assert.Nil(t, newLogParser(config.ApplicationConfig{LogFormat: config.LogFormatText}))
+
+	// A nil parser leaves lines as they are
+	line := LogLine{Message: `{"level":"info"}`}
+	newLogParser(config.ApplicationConfig{}).parse(&line)
+	assert.Equal(t, LogLine{Message: `{"level":"info"}`}, line)
+}
+
+func TestLogParserJSON(t *testing.T) {
+	parser := newLogParser(config.ApplicationConfig{LogFormat: config.LogFormatJSON})
+
+	line := LogLine{Timestamp: time.Now(), Message: `{"level":"WARN","msg":"disk nearly full","time":"2025-08-07T12:00:00Z","disk":"/dev/sda1","used":0.93,"tags":["a", "b"]}`}
+	parser.parse(&line)
+
+	assert.Equal(t, "warn", line.Level)
+	assert.Equal(t, "disk nearly full", line.Message)
+	assert.Equal(t, time.Date(2025, 8, 7, 12, 0, 0, 0, time.UTC), line.Timestamp.UTC())
+	assert.Equal(t, map[string]string{"disk": "/dev/sda1", "used": "0.93", "tags": `["a","b"]`}, line.Fields)
+
+	// Lines which aren't JSON objects are left as they are
+	for _, message := range []string{"plain text", `{"unterminated": `, `["an", "array"]`} {
+		line := LogLine{Message: message}
+		parser.parse(&line)
+		assert.Equal(t, LogLine{Message: message}, line)
+	}
+}
+
+func TestLogParserJSONUnixTimestamps(t *testing.T) {
+	parser := newLogParser(config.ApplicationConfig{LogFormat: config.LogFormatJSON})
+
+	line := LogLine{Message: `{"ts":1754568000.5,"msg":"seconds"}`}
+	parser.parse(&line)
+	assert.Equal(t, time.Date(2025, 8, 7, 12, 0, 0, 500000000, time.UTC), line.Timestamp.UTC())
+
+	line = LogLine{Message: `{"ts":1754568000123,"msg":"milliseconds"}`}
+	parser.parse(&line)
+	assert.Equal(t, time.Date(2025, 8, 7, 12, 0, 0, 123000000, time.UTC), line.Timestamp.UTC().Round(time.Millisecond))
+
+	// Timestamps which can't be parsed are kept as fields
+	now := time.Now()
+	line = LogLine{Timestamp: now, Message: `{"time":"yesterday","msg":"unparseable"}`}
+	parser.parse(&line)
+	assert.Equal(t, now, line.Timestamp)
+	assert.Equal(t, map[string]string{"time": "yesterday"}, line.Fields)
+}
+
+func TestLogParserCustomFields(t *testing.T) {
+	parser := newLogParser(config.ApplicationConfig{
+		LogFormat: config.LogFormatJSON,
+		LogFields: config.LogFieldsConfig{Level: "severity", Message: "event", Timestamp: "@timestamp"},
+	})
+
+	line := LogLine{Message: `{"severity":"Error","event":"failed","@timestamp":"2025-08-07T12:00:00Z","level":"ignored"}`}
+	parser.parse(&line)
+
+	assert.Equal(t, "error", line.Level)
+	assert.Equal(t, "failed", line.Message)
+	assert.Equal(t, map[string]string{"level": "ignored"}, line.Fields)
+}
+
+func TestLogParserLogfmt(t *testing.T) {
+	parser := newLogParser(config.ApplicationConfig{LogFormat: config.LogFormatLogfmt})
+
+	line := LogLine{Message: `time=2025-08-07T12:00:00Z level=info msg="request \"handled\"" path=/api status=200 cached`}
+	parser.parse(&line)
+
+	assert.Equal(t, "info", line.Level)
+	assert.Equal(t, `request "handled"`, line.Message)
+	assert.Equal(t, time.Date(2025, 8, 7, 12, 0, 0, 0, time.UTC), line.Timestamp.UTC())
+	assert.Equal(t, map[string]string{"path": "/api", "status": "200", "cached": ""}, line.Fields)
+
+	for _, message := range []string{"just some words", `msg="unterminated`, "=value"} {
+		line := LogLine{Message: message}
+		parser.parse(&line)
+		assert.Equal(t, LogLine{Message: message}, line, message)
+	}
+}
+
+func TestCollectLogsStructured(t *testing.T) {
+	manager := NewManager([]config.ApplicationConfig{
+		{
+			Name:      "structured",
+			Path:      "/bin/sh",
+			Args:      []string{"-c", `echo '{"level":"error","msg":"boom","code":7}'; echo 'not json'`},
+			LogFormat: config.LogFormatJSON,
+		},
+	})
+
+	require.NoError(t, manager.StartApp(context.Background(), "structured"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "structured"))
+
+	logs, err := manager.GetLogs("structured")
+	require.NoError(t, err)
+
+	var output []LogLine
+	for _, line := range logs {
+		if line.Source == "stdout" {
+			output = append(output, line)
+		}
+	}
+
+	require.Len(t, output, 2)
+	assert.Equal(t, "boom", output[0].Message)
+	assert.Equal(t, "error", output[0].Level)
+	assert.Equal(t, map[string]string{"code": "7"}, output[0].Fields)
+	assert.Equal(t, "not json", output[1].Message)
+	assert.Empty(t, output[1].Level)
+}
@@ -0,0 +1,170 @@
+package apps
+
+import (
+	"errors"
+	"fmt"
+)
+
+// LogQuery selects a range of an application's log lines using their sequence numbers
+type LogQuery struct {
+	// Only return lines with a sequence number greater than After
+	After uint64
+	// Only return lines with a sequence number less than Before (0 for no upper bound)
+	Before uint64
+	// The maximum number of lines to return (0 for no limit). When After is set the
+	// oldest matching lines are returned, otherwise the most recent ones are.
+	Limit int
+	// Read the lines which have been persisted to disk, rather than only those in memory
+	History bool
+	// Selects the lines to return as each line in the range is read, which may include lines
+	// read before it (nil selects every line). The limit applies to the lines it selects.
+	Select func(LogLine) []LogLine
+}
+
+// matches returns true if the line falls within the query's range
+func (q LogQuery) matches(line LogLine) bool {
+	if line.Seq <= q.After {
+		return false
+	}
+
+	return q.Before == 0 || line.Seq < q.Before
+}
+
+// apply filters the lines, which must be in sequence order, down to those selected by the query
+func (q LogQuery) apply(lines []LogLine) []LogLine {
+	selection := newLogSelection(q)
+	for _, line := range lines {
+		if selection.add(line) != nil {
+			break
+		}
+	}
+
+	return selection.result()
+}
+
+// errSelectionDone is returned by logSelection.add once no later line can be selected
+var errSelectionDone = errors.New("no more log lines can be selected")
+
+// logSelection collects the lines selected by a query as they are read in sequence order,
+// holding on to no more of them than the query's limit requires
+type logSelection struct {
+	query LogQuery
+	lines []LogLine
+}
+
+func newLogSelection(query LogQuery) *logSelection {
+	return &logSelection{query: query, lines: []LogLine{}}
+}
+
+// add selects the line if the query matches it, returning errSelectionDone once no later
+// line can be selected
+func (s *logSelection) add(line LogLine) error {
+	if s.query.Before != 0 && line.Seq >= s.query.Before {
+		return errSelectionDone
+	}
+
+	if !s.query.matches(line) {
+		return nil
+	}
+
+	if s.query.Select != nil {
+		s.lines = append(s.lines, s.query.Select(line)...)
+	} else {
+		s.lines = append(s.lines, line)
+	}
+
+	limit := s.query.Limit
+	if limit <= 0 {
+		return nil
+	}
+
+	// Reading forwards from a cursor keeps the oldest lines, so the rest needn't be read
+	if s.query.After > 0 {
+		if len(s.lines) >= limit {
+			return errSelectionDone
+		}
+
+		return nil
+	}
+
+	// Avoid holding on to more lines than we need
+	if len(s.lines) > 2*limit {
+		s.lines = append(s.lines[:0], s.lines[len(s.lines)-limit:]...)
+	}
+
+	return nil
+}
+
+// result returns the selected lines, oldest first
+func (s *logSelection) result() []LogLine {
+	limit := s.query.Limit
+	if limit <= 0 || len(s.lines) <= limit {
+		return s.lines
+	}
+
+	if s.query.After > 0 {
+		return s.lines[:limit]
+	}
+
+	return s.lines[len(s.lines)-limit:]
+}
+
+// QueryLogs returns the application's log lines which are selected by the query, oldest first
+func (m *Manager) QueryLogs(name string, query LogQuery) ([]LogLine, error) {
+	m.mux.RLock()
+	app, exists := m.apps[name]
+	m.mux.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("application %s not found", name)
+	}
+
+	if query.History && app.store != nil {
+		// Only the most recent lines need to be kept while reading the whole history back
+		if query.After == 0 && query.Before == 0 && query.Select == nil && query.Limit > 0 {
+			return app.store.tail(query.Limit)
+		}
+
+		selection := newLogSelection(query)
+		if err := app.store.walk(selection.add); err != nil && !errors.Is(err, errSelectionDone) {
+			return nil, err
+		}
+
+		return selection.result(), nil
+	}
+
+	app.logMux.RLock()
+	defer app.logMux.RUnlock()
+
+	return query.apply(app.logs.snapshot()), nil
+}
+
+// WalkLogs calls fn with each of the application's log lines, oldest first, including those which
+// have been persisted to disk. Persisted lines are read one at a time rather than being loaded into
+// memory together, and walking stops at the first error which fn returns.
+func (m *Manager) WalkLogs(name string, fn func(LogLine) error) error {
+	m.mux.RLock()
+	app, exists := m.apps[name]
+	m.mux.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("application %s not found", name)
+	}
+
+	if app.store != nil {
+		return app.store.walk(fn)
+	}
+
+	// The lines are copied so that logging isn't blocked while fn is called
+	app.logMux.RLock()
+	lines := app.logs.snapshot()
+	app.logMux.RUnlock()
+
+	for _, line := range lines {
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package apps
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+func TestLogQueryApply(t *testing.T) {
+	lines := make([]LogLine, 10)
+	for i := range lines {
+		lines[i] = LogLine{Seq: uint64(i + 1), Message: strconv.Itoa(i + 1)}
+	}
+
+	seqs := func(lines []LogLine) []uint64 {
+		result := make([]uint64, len(lines))
+		for i, line := range lines {
+			result[i] = line.Seq
+		}
+		return result
+	}
+
+	assert.Len(t, LogQuery{}.apply(lines), 10)
+	assert.Equal(t, []uint64{8, 9, 10}, seqs(LogQuery{After: 7}.apply(lines)))
+	assert.Equal(t, []uint64{1, 2}, seqs(LogQuery{Before: 3}.apply(lines)))
+	assert.Equal(t, []uint64{4, 5}, seqs(LogQuery{After: 3, Before: 6}.apply(lines)))
+	assert.Equal(t, []uint64{9, 10}, seqs(LogQuery{Limit: 2}.apply(lines)), "the newest lines are returned without a cursor")
+	assert.Equal(t, []uint64{4, 5}, seqs(LogQuery{After: 3, Limit: 2}.apply(lines)), "the oldest lines after the cursor are returned")
+	assert.Equal(t, []uint64{3, 4}, seqs(LogQuery{Before: 5, Limit: 2}.apply(lines)), "paging backwards returns the lines just before the cursor")
+	assert.Empty(t, LogQuery{After: 10}.apply(lines))
+
+	// The limit applies to the lines which are selected, rather than those which are read
+	even := func(line LogLine) []LogLine {
+		if line.Seq%2 == 0 {
+			return []LogLine{line}
+		}
+		return nil
+	}
+	assert.Equal(t, []uint64{8, 10}, seqs(LogQuery{Limit: 2, Select: even}.apply(lines)))
+	assert.Equal(t, []uint64{4, 6}, seqs(LogQuery{After: 3, Limit: 2, Select: even}.apply(lines)))
+	assert.Equal(t, []uint64{2, 4}, seqs(LogQuery{Before: 6, Select: even}.apply(lines)))
+}
+
+func TestQueryLogsHistory(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name: "chatty",
+			Path: "/bin/sh",
+			Args: []string{"-c", "seq 1 1500"},
+			LogStorage: &config.LogStorageConfig{
+				Directory: t.TempDir(),
+			},
+		},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "chatty"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "chatty"))
+
+	messages := func(lines []LogLine) []string {
+		result := make([]string, len(lines))
+		for i, line := range lines {
+			result[i] = line.Message
+		}
+		return result
+	}
+
+	// Lines which are no longer in memory are read back from disk
+	logs, err := manager.QueryLogs("chatty", LogQuery{History: true, Limit: 2000})
+	require.NoError(t, err)
+	assert.Contains(t, messages(logs), "1")
+
+	endsInZero := func(line LogLine) []LogLine {
+		if line.Source == "stdout" && strings.HasSuffix(line.Message, "00") {
+			return []LogLine{line}
+		}
+		return nil
+	}
+
+	logs, err = manager.QueryLogs("chatty", LogQuery{History: true, Limit: 2, Select: endsInZero})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1400", "1500"}, messages(logs))
+
+	logs, err = manager.QueryLogs("chatty", LogQuery{History: true, After: 1, Limit: 2, Select: endsInZero})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"100", "200"}, messages(logs))
+}
+
+func TestQueryLogsSequence(t *testing.T) {
+	manager := NewManager([]config.ApplicationConfig{
+		{
+			Name: "chatty",
+			Path: "/bin/sh",
+			Args: []string{"-c", "seq 1 1500"},
+		},
+	})
+
+	require.NoError(t, manager.StartApp(context.Background(), "chatty"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "chatty"))
+
+	logs, err := manager.QueryLogs("chatty", LogQuery{})
+	require.NoError(t, err)
+	require.Len(t, logs, maxLogLines)
+
+	// Sequence numbers keep increasing once the buffer is full
+	for i := 1; i < len(logs); i++ {
+		assert.Equal(t, logs[i-1].Seq+1, logs[i].Seq)
+	}
+	last := logs[len(logs)-1].Seq
+	assert.Greater(t, last, uint64(1500))
+
+	logs, err = manager.QueryLogs("chatty", LogQuery{After: last - 5})
+	require.NoError(t, err)
+	assert.Len(t, logs, 5)
+
+	_, err = manager.QueryLogs("missing", LogQuery{})
+	assert.Error(t, err)
+}
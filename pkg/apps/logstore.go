@@ -0,0 +1,343 @@
+package apps
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+const (
+	defaultLogMaxBytes = 10 * 1024 * 1024
+	defaultLogMaxFiles = 5
+)
+
+// logStore persists an application's log lines to newline delimited JSON files on disk,
+// rotating the current file once it grows too large or too old.
+type logStore struct {
+	dir      string
+	name     string
+	maxBytes int64
+	maxAge   time.Duration
+	maxFiles int
+
+	mux  sync.Mutex
+	file *os.File
+	size int64
+	// When the current file's first line was written, which its age is measured from. This is
+	// tailon's own time, as lines' timestamps may come from the application or a replayed file.
+	startedAt time.Time
+}
+
+func newLogStore(name string, cfg *config.LogStorageConfig) *logStore {
+	store := &logStore{
+		dir:      cfg.Directory,
+		name:     name,
+		maxBytes: cfg.MaxBytes,
+		maxAge:   cfg.MaxAge.Duration(),
+		maxFiles: cfg.MaxFiles,
+	}
+
+	if store.maxBytes <= 0 {
+		store.maxBytes = defaultLogMaxBytes
+	}
+
+	if store.maxFiles <= 0 {
+		store.maxFiles = defaultLogMaxFiles
+	}
+
+	return store
+}
+
+// currentPath is the path of the file which new log lines are appended to
+func (s *logStore) currentPath() string {
+	return filepath.Join(s.dir, s.name+".log")
+}
+
+// write appends the log line to the current log file, rotating it first if necessary
+func (s *logStore) write(line LogLine) error {
+	data, err := json.Marshal(line)
+	if err != nil {
+		return fmt.Errorf("failed to encode log line: %w", err)
+	}
+	data = append(data, '\n')
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	// A file which was reopened may already be due to be rotated
+	now := time.Now()
+	if s.shouldRotate(int64(len(data)), now) {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	if s.startedAt.IsZero() {
+		s.startedAt = now
+	}
+
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write log file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *logStore) shouldRotate(pending int64, now time.Time) bool {
+	if s.size > 0 && s.size+pending > s.maxBytes {
+		return true
+	}
+
+	return s.maxAge > 0 && !s.startedAt.IsZero() && now.Sub(s.startedAt) >= s.maxAge
+}
+
+// open opens (or creates) the current log file for appending. The store's lock must be held.
+func (s *logStore) open() error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(s.currentPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	s.file = file
+	s.size = info.Size()
+	s.startedAt = time.Time{}
+
+	// A file which was written before tailon restarted is as old as its first line, so that
+	// restarting tailon doesn't postpone its rotation. That line's timestamp may have come from
+	// the application, but the line can't have been written after the file was last modified.
+	if s.size > 0 {
+		s.startedAt = firstLogTime(s.currentPath(), info.ModTime())
+		if s.startedAt.After(info.ModTime()) {
+			s.startedAt = info.ModTime()
+		}
+	}
+
+	return nil
+}
+
+// firstLogTime returns when the first line in the log file was logged, or the fallback if it
+// can't be read
+func firstLogTime(path string, fallback time.Time) time.Time {
+	file, err := os.Open(path)
+	if err != nil {
+		return fallback
+	}
+	defer file.Close()
+
+	data, err := bufio.NewReader(file).ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return fallback
+	}
+
+	var line LogLine
+	if err := json.Unmarshal(data, &line); err != nil || line.Timestamp.IsZero() {
+		return fallback
+	}
+
+	return line.Timestamp
+}
+
+// rotate moves the current log file aside and removes any rotated files which exceed
+// the retention count. The store's lock must be held.
+func (s *logStore) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	s.file = nil
+
+	rotated := filepath.Join(s.dir, fmt.Sprintf("%s-%s.log", s.name, time.Now().UTC().Format("20060102T150405.000000000")))
+	if err := os.Rename(s.currentPath(), rotated); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	files, err := s.rotatedFiles()
+	if err != nil {
+		return err
+	}
+
+	for len(files) > s.maxFiles {
+		if err := os.Remove(files[0]); err != nil {
+			return fmt.Errorf("failed to remove old log file: %w", err)
+		}
+		files = files[1:]
+	}
+
+	return nil
+}
+
+// rotatedFiles returns the paths of the rotated log files, oldest first
+func (s *logStore) rotatedFiles() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, s.name+"-*.log"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log files: %w", err)
+	}
+
+	// Other applications' files may match the pattern if their name starts with ours
+	matching := files[:0]
+	for _, file := range files {
+		suffix := strings.TrimPrefix(filepath.Base(file), s.name+"-")
+		if _, err := time.Parse("20060102T150405.000000000.log", suffix); err == nil {
+			matching = append(matching, file)
+		}
+	}
+
+	// The timestamps in the file names sort chronologically
+	sort.Strings(matching)
+	return matching, nil
+}
+
+// files returns the paths of all of the store's log files, oldest first
+func (s *logStore) files() ([]string, error) {
+	files, err := s.rotatedFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(s.currentPath()); err == nil {
+		files = append(files, s.currentPath())
+	}
+
+	return files, nil
+}
+
+// tail returns up to the last n log lines which have been persisted, oldest first.
+// A limit of zero or less returns every persisted line.
+func (s *logStore) tail(n int) ([]LogLine, error) {
+	var lines []LogLine
+	if err := s.walk(func(line LogLine) error {
+		lines = append(lines, line)
+		if n > 0 && len(lines) > 2*n {
+			// Avoid holding on to more lines than we need
+			lines = append(lines[:0], lines[len(lines)-n:]...)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if n > 0 && len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+
+	return lines, nil
+}
+
+// walk calls fn for each persisted log line, oldest first, stopping at the first error it returns
+func (s *logStore) walk(fn func(LogLine) error) error {
+	// The files are read without holding the lock so that the application isn't blocked
+	// from writing logs in the meantime.
+	s.mux.Lock()
+	files, err := s.files()
+	s.mux.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range files {
+		if err := readLogFile(path, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readLogFile calls fn for each log line in the file, skipping any which cannot be decoded,
+// and stops at the first error which fn returns
+func readLogFile(path string, fn func(LogLine) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// The file may have been removed by a rotation
+			return nil
+		}
+
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	// Lines are read without a length limit, as long output lines may be even longer once encoded
+	reader := bufio.NewReader(file)
+	for {
+		data, err := reader.ReadBytes('\n')
+		if len(data) > 0 {
+			var line LogLine
+			if err := json.Unmarshal(data, &line); err == nil {
+				if err := fn(line); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to read log file: %w", err)
+		}
+	}
+}
+
+// close closes the current log file, which will be reopened if another line is written
+func (s *logStore) close() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// closeLogStores closes every application's current log file
+func (m *Manager) closeLogStores() {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	for name, app := range m.apps {
+		if app.store == nil {
+			continue
+		}
+
+		if err := app.store.close(); err != nil {
+			logrus.WithField("app", name).WithError(err).Warn("Failed to close log file")
+		}
+	}
+}
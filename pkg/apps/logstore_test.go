@@ -0,0 +1,198 @@
+package apps
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogStoreRotation(t *testing.T) {
+	dir := t.TempDir()
+	store := newLogStore("app", &config.LogStorageConfig{
+		Directory: dir,
+		MaxBytes:  512,
+		MaxFiles:  2,
+	})
+	defer store.close()
+
+	for i := range 100 {
+		require.NoError(t, store.write(LogLine{Timestamp: time.Now(), Message: fmt.Sprintf("line %d", i), Source: "stdout"}))
+	}
+
+	rotated, err := store.rotatedFiles()
+	require.NoError(t, err)
+	assert.Len(t, rotated, 2, "only max_files rotated files should be retained")
+
+	for _, path := range append(rotated, store.currentPath()) {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(512))
+	}
+
+	lines, err := store.tail(0)
+	require.NoError(t, err)
+	require.NotEmpty(t, lines)
+	assert.Less(t, len(lines), 100, "lines in files beyond the retention count are removed")
+	assert.Equal(t, "line 99", lines[len(lines)-1].Message)
+
+	lines, err = store.tail(3)
+	require.NoError(t, err)
+	require.Len(t, lines, 3)
+	assert.Equal(t, "line 97", lines[0].Message)
+	assert.Equal(t, "stdout", lines[0].Source)
+}
+
+func TestLogStoreRotationByAge(t *testing.T) {
+	dir := t.TempDir()
+	store := newLogStore("app", &config.LogStorageConfig{
+		Directory: dir,
+		MaxAge:    config.Duration(time.Hour),
+	})
+	defer store.close()
+
+	// The file's age is measured using tailon's clock, rather than the timestamps the
+	// application logged, which may be far in the past or future
+	require.NoError(t, store.write(LogLine{Timestamp: time.Now().Add(-24 * time.Hour), Message: "first"}))
+	require.NoError(t, store.write(LogLine{Timestamp: time.Now().Add(24 * time.Hour), Message: "second"}))
+
+	rotated, err := store.rotatedFiles()
+	require.NoError(t, err)
+	assert.Empty(t, rotated)
+
+	// Once the file has been written to for longer than its maximum age, it is rotated
+	store.startedAt = time.Now().Add(-2 * time.Hour)
+	require.NoError(t, store.write(LogLine{Timestamp: time.Now().Add(-24 * time.Hour), Message: "third"}))
+
+	rotated, err = store.rotatedFiles()
+	require.NoError(t, err)
+	assert.Len(t, rotated, 1)
+
+	lines, err := store.tail(0)
+	require.NoError(t, err)
+	require.Len(t, lines, 3)
+	assert.Equal(t, "first", lines[0].Message)
+	assert.Equal(t, "third", lines[2].Message)
+}
+
+func TestLogStoreRotationByAgeAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.LogStorageConfig{
+		Directory: dir,
+		MaxAge:    config.Duration(time.Hour),
+	}
+
+	// The file was started before tailon restarted
+	store := newLogStore("app", cfg)
+	require.NoError(t, store.write(LogLine{Timestamp: time.Now().Add(-80 * time.Minute), Message: "first"}))
+	require.NoError(t, store.close())
+
+	// The file's age is measured from its first line, rather than from when it was reopened
+	store = newLogStore("app", cfg)
+	defer store.close()
+	require.NoError(t, store.write(LogLine{Timestamp: time.Now(), Message: "second"}))
+
+	rotated, err := store.rotatedFiles()
+	require.NoError(t, err)
+	assert.Len(t, rotated, 1)
+
+	lines, err := store.tail(1)
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "second", lines[0].Message)
+
+	// A file whose first line has a timestamp in the future is no newer than the file itself
+	future := newLogStore("future", cfg)
+	require.NoError(t, future.write(LogLine{Timestamp: time.Now().Add(24 * time.Hour), Message: "first"}))
+	require.NoError(t, future.close())
+
+	future = newLogStore("future", cfg)
+	defer future.close()
+	require.NoError(t, future.write(LogLine{Timestamp: time.Now(), Message: "second"}))
+	assert.False(t, future.startedAt.After(time.Now()))
+}
+
+func TestLogStoreWalk(t *testing.T) {
+	store := newLogStore("app", &config.LogStorageConfig{
+		Directory: t.TempDir(),
+		MaxBytes:  512,
+	})
+	defer store.close()
+
+	for i := range 20 {
+		require.NoError(t, store.write(LogLine{Timestamp: time.Now(), Message: fmt.Sprintf("line %d", i)}))
+	}
+
+	// Lines are read from every file in order
+	var messages []string
+	require.NoError(t, store.walk(func(line LogLine) error {
+		messages = append(messages, line.Message)
+		return nil
+	}))
+	require.Len(t, messages, 20)
+	assert.Equal(t, "line 0", messages[0])
+	assert.Equal(t, "line 19", messages[19])
+
+	// Reading stops at the first error
+	stop := fmt.Errorf("stop")
+	read := 0
+	assert.Equal(t, stop, store.walk(func(line LogLine) error {
+		read++
+		if read == 5 {
+			return stop
+		}
+		return nil
+	}))
+	assert.Equal(t, 5, read)
+}
+
+func TestLogStoreIgnoresOtherApplications(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app-server-20250101T000000.000000000.log"), []byte("{}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app-20250101T000000.000000000.log"), []byte("{}\n"), 0o644))
+
+	store := newLogStore("app", &config.LogStorageConfig{Directory: dir})
+	rotated, err := store.rotatedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "app-20250101T000000.000000000.log")}, rotated)
+}
+
+func TestPersistedLogsSurviveRestart(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name: "persisted",
+			Path: "/bin/echo",
+			Args: []string{"hello from the past"},
+			LogStorage: &config.LogStorageConfig{
+				Directory: t.TempDir(),
+			},
+		},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "persisted"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "persisted"))
+	require.NoError(t, manager.Shutdown(context.Background()))
+
+	// A new manager (as if tailon had restarted) loads the persisted logs
+	manager = NewManager(configs)
+	logs, err := manager.GetLogs("persisted")
+	require.NoError(t, err)
+
+	messages := make([]string, 0, len(logs))
+	for _, log := range logs {
+		messages = append(messages, log.Message)
+	}
+	assert.Contains(t, messages, "hello from the past")
+
+	history, err := manager.QueryLogs("persisted", LogQuery{History: true, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, logs[len(logs)-1], history[0])
+}
@@ -1,35 +1,68 @@
 package apps
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"regexp"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/sierrasoftworks/tailon/pkg/logsink"
 	"github.com/sierrasoftworks/tailon/pkg/userctx"
 	"github.com/sirupsen/logrus"
 )
 
+// maxLogLines is the number of log lines kept in memory for applications which do not
+// configure their log_buffer.
 const maxLogLines = 1000
 
+// outputDrainTimeout is how long we wait for an exited process' output to be closed
+// (it may be held open by orphaned child processes) before we stop reading it.
+const outputDrainTimeout = 100 * time.Millisecond
+
 // ApplicationState represents the current state of an application
 type ApplicationState string
 
 const (
 	StateNotRunning ApplicationState = "not_running"
-	StateRunning    ApplicationState = "running"
-	StateStopping   ApplicationState = "stopping"
+	// StateStarting indicates that the application's process is running, but it
+	// has not yet printed the output which indicates that it is ready.
+	StateStarting ApplicationState = "starting"
+	StateRunning  ApplicationState = "running"
+	StateStopping ApplicationState = "stopping"
+	// StateBackoff indicates that the application exited and is waiting for
+	// its restart policy to start it again.
+	StateBackoff ApplicationState = "backoff"
+	// StateFailed indicates that the application was crash looping and will not
+	// be restarted again until a user starts it.
+	StateFailed ApplicationState = "failed"
 )
 
 type LogLine struct {
+	// A per-application sequence number which increases with every line
+	Seq       uint64    `json:"seq"`
 	Timestamp time.Time `json:"timestamp"`
 	Message   string    `json:"message"`
 	Source    string    `json:"source"`
+	// The level of a structured log line, in lower case
+	Level string `json:"level,omitempty"`
+	// The fields of a structured log line, other than its level, message and timestamp
+	Fields map[string]string `json:"fields,omitempty"`
+	// Whether the line continues the previous one, as the application's output line was too
+	// long to be logged whole
+	Continued bool `json:"continued,omitempty"`
+	// The styled runs of text which make up the message, for applications which convert
+	// the ANSI escape sequences in their output
+	Spans []LogSpan `json:"spans,omitempty"`
+	// The ID of the application's run which the line was logged during, if any
+	Run uint64 `json:"run,omitempty"`
 }
 
 type Application struct {
@@ -39,36 +72,149 @@ type Application struct {
 	LastExitCode   int                      `json:"last_exit_code"`
 	StateChangedBy *userctx.User            `json:"state_changed_by,omitempty"`
 	StateChangedAt *time.Time               `json:"state_changed_at,omitempty"`
-	logs           []LogLine
-	logMux         sync.RWMutex
-	cmd            *exec.Cmd
-	cancel         context.CancelFunc
+	RestartCount   int                      `json:"restart_count"`
+	NextRestartAt  *time.Time               `json:"next_restart_at,omitempty"`
+	Failure        *FailureInfo             `json:"failure,omitempty"`
+	Health         *HealthState             `json:"health,omitempty"`
+	// The signal which terminated the application's last process, if any, in which case its
+	// exit code is -1
+	LastExitSignal string `json:"last_exit_signal,omitempty"`
+	// Whether the last process dumped core when the signal terminated it
+	LastCoreDumped bool `json:"last_core_dumped,omitempty"`
+	// Whether the signal which terminated the last process was sent by tailon, such as when
+	// stopping it, rather than by something else
+	LastSignalSentByTailon bool `json:"last_signal_sent_by_tailon,omitempty"`
+	// The application's process and its descendants (only populated by GetApp)
+	Processes []ProcessInfo `json:"processes,omitempty"`
+	// Processes from the application's process group which were still running after it exited
+	LeftoverProcesses []ProcessInfo `json:"leftover_processes,omitempty"`
+	// How much of the application's in-memory log buffer is in use (only populated by GetApp)
+	LogBuffer *LogBufferUsage `json:"log_buffer,omitempty"`
+	// The number of secrets which have been redacted from the application's logs
+	Redactions uint64 `json:"redactions"`
+
+	logs    *logBuffer
+	lastSeq uint64
+	// The run which new log lines are tagged with (guarded by logMux)
+	logRun       uint64
+	logMux       sync.RWMutex
+	subscribers  map[*LogSubscription]struct{}
+	store        *logStore
+	storeFailing bool
+	// Follows the log files written by the application
+	tailer *logFileTailer
+	// Removes secrets from the application's output, counting how many it has removed
+	redactor *redactor
+	redacted atomic.Uint64
+	// The sinks which the application's log lines are forwarded to, along with the name of
+	// this instance of tailon which is included in them (both guarded by logMux)
+	forwarders []*logsink.Forwarder
+	node       string
+	// The sinks which were created for this application alone, rather than shared
+	sinks []*logsink.Forwarder
+	// The application's running process and when it started (in clock ticks after boot), which
+	// may have been adopted from an earlier instance of tailon rather than started by this one
+	process       *os.Process
+	processStart  uint64
+	stopping      *stopRequest
+	run           uint64
+	startedAt     time.Time
+	stopRequested bool
+	// Whether the application should be started again as soon as its process exits
+	restartRequested bool
+	restartTimer     *time.Timer
+	recentExits      []exitRecord
+	// The application's most recent runs, oldest first
+	runs       []RunRecord
+	exited     chan struct{}
+	ready      chan struct{}
+	readyTimer *time.Timer
+	// Why the current run failed to start, which is reported once its process exits
+	startFailure string
+	// Collects the output of the application's process
+	output *outputCapture
+	// Whether the application's process was left running when tailon shut down, so that its
+	// exit is no longer recorded by this instance of tailon
+	detached bool
 }
 
-// IsRunning returns true if the application is currently running
+// IsRunning returns true if the application's process is currently running, even
+// if it has not yet become ready
 func (a *Application) IsRunning() bool {
-	return a.State == StateRunning
+	return a.State == StateRunning || a.State == StateStarting
+}
+
+// snapshot returns a copy of the application's public state which can safely
+// be handed to callers. The manager's lock must be held while calling it.
+func (a *Application) snapshot() *Application {
+	return &Application{
+		Config:                 a.Config,
+		State:                  a.State,
+		PID:                    a.PID,
+		StateChangedBy:         a.StateChangedBy,
+		StateChangedAt:         a.StateChangedAt,
+		LastExitCode:           a.LastExitCode,
+		LastExitSignal:         a.LastExitSignal,
+		LastCoreDumped:         a.LastCoreDumped,
+		LastSignalSentByTailon: a.LastSignalSentByTailon,
+		RestartCount:           a.RestartCount,
+		NextRestartAt:          a.NextRestartAt,
+		Failure:                a.Failure,
+		Health:                 a.Health,
+		LeftoverProcesses:      a.LeftoverProcesses,
+		Redactions:             a.redacted.Load(),
+	}
 }
 
 type Manager struct {
-	apps map[string]*Application
-	mux  sync.RWMutex
+	apps         map[string]*Application
+	mux          sync.RWMutex
+	shuttingDown bool
+	// The log sinks which every application's log lines are forwarded to
+	sinks []*logsink.Forwarder
+	// The file which the manager's state is persisted to, if any
+	statePath    string
+	stateFailing bool
+	// Whether applications are left running when tailon shuts down
+	keepRunning bool
 }
 
 func NewManager(configs []config.ApplicationConfig) *Manager {
 	apps := make(map[string]*Application)
 	for _, cfg := range configs {
-		apps[cfg.Name] = &Application{
+		app := &Application{
 			Config:       cfg,
 			State:        StateNotRunning,
 			LastExitCode: 0,
-			logs:         make([]LogLine, 0, maxLogLines),
+			logs:         newLogBuffer(cfg.LogBuffer),
+			redactor:     newRedactor(cfg),
+		}
+
+		if cfg.LogStorage != nil {
+			app.store = newLogStore(cfg.Name, cfg.LogStorage)
+
+			// Pick up where we left off the last time tailon was running
+			logs, err := app.store.tail(app.logs.maxLines)
+			if err != nil {
+				logrus.WithField("app", cfg.Name).WithError(err).Warn("Failed to load persisted logs")
+			}
+
+			for _, line := range logs {
+				app.logs.push(line)
+				app.lastSeq = max(app.lastSeq, line.Seq)
+				// Run IDs carry on from those in the persisted logs
+				app.run = max(app.run, line.Run)
+			}
 		}
+
+		apps[cfg.Name] = app
 	}
 
-	return &Manager{
+	m := &Manager{
 		apps: apps,
 	}
+	m.startLogFileTailers()
+	return m
 }
 
 func (m *Manager) GetApps() map[string]*Application {
@@ -77,50 +223,97 @@ func (m *Manager) GetApps() map[string]*Application {
 
 	result := make(map[string]*Application)
 	for name, app := range m.apps {
-		result[name] = &Application{
-			Config:         app.Config,
-			State:          app.State,
-			PID:            app.PID,
-			StateChangedBy: app.StateChangedBy,
-			StateChangedAt: app.StateChangedAt,
-			LastExitCode:   app.LastExitCode,
-		}
+		result[name] = app.snapshot()
 	}
 	return result
 }
 
 func (m *Manager) GetApp(name string) (*Application, error) {
 	m.mux.RLock()
-	defer m.mux.RUnlock()
 
 	app, exists := m.apps[name]
 	if !exists {
+		m.mux.RUnlock()
 		return nil, fmt.Errorf("application %s not found", name)
 	}
 
-	return &Application{
-		Config:         app.Config,
-		State:          app.State,
-		PID:            app.PID,
-		StateChangedBy: app.StateChangedBy,
-		StateChangedAt: app.StateChangedAt,
-		LastExitCode:   app.LastExitCode,
-	}, nil
+	snapshot := app.snapshot()
+	m.mux.RUnlock()
+
+	if snapshot.PID != 0 {
+		processes, err := processTree(snapshot.PID)
+		if err != nil {
+			logrus.WithField("app", name).WithError(err).Debug("Failed to get application process tree")
+		}
+		snapshot.Processes = processes
+	}
+
+	app.logMux.RLock()
+	usage := app.logs.usage()
+	app.logMux.RUnlock()
+	snapshot.LogBuffer = &usage
+
+	return snapshot, nil
 }
 
+// StartApp starts the application, first starting any of its dependencies which are not
+// already running.
 func (m *Manager) StartApp(ctx context.Context, name string) error {
-	m.mux.Lock()
-	defer m.mux.Unlock()
+	_, err := m.StartAppWithDependencies(ctx, name)
+	return err
+}
+
+// canStart returns an error if the application cannot currently be started
+func (m *Manager) canStart(name string) error {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	_, err := m.startable(name)
+	return err
+}
 
+// startable looks up an application, returning an error if it cannot currently be started.
+// The manager's lock must be held while calling it.
+func (m *Manager) startable(name string) (*Application, error) {
 	app, exists := m.apps[name]
 	if !exists {
-		return fmt.Errorf("application %s not found", name)
+		return nil, fmt.Errorf("application %s not found", name)
 	}
 
 	if app.IsRunning() {
-		return fmt.Errorf("application %s is already running", name)
+		return nil, fmt.Errorf("application %s is already running", name)
+	}
+
+	if app.State == StateStopping {
+		return nil, fmt.Errorf("application %s is still stopping", name)
+	}
+
+	if m.shuttingDown {
+		return nil, fmt.Errorf("application %s cannot be started while tailon is shutting down", name)
 	}
 
+	return app, nil
+}
+
+// startApp starts the application, without considering its dependencies, recording the
+// provided audit message.
+func (m *Manager) startApp(ctx context.Context, name string, auditMsg string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	app, err := m.startable(name)
+	if err != nil {
+		return err
+	}
+
+	return m.start(ctx, app, auditMsg)
+}
+
+// start starts the application on behalf of the context's user, recording the provided audit
+// message. The manager's lock must be held while calling it.
+func (m *Manager) start(ctx context.Context, app *Application, auditMsg string) error {
+	name := app.Config.Name
+
 	// Get user from context
 	user := userctx.FromContext(ctx)
 	logger := userctx.GetLoggerFromContext(ctx)
@@ -133,8 +326,22 @@ func (m *Manager) StartApp(ctx context.Context, name string) error {
 		"event":  event,
 	}).Info("User started application")
 
-	cmdCtx, cancel := context.WithCancel(context.Background())
-	cmd := exec.CommandContext(cmdCtx, app.Config.Path, app.Config.Args...)
+	// A manual start takes precedence over any pending automatic restart
+	app.cancelRestart()
+	app.RestartCount = 0
+	app.recentExits = nil
+	app.Failure = nil
+
+	return m.launch(app, user, logger, auditMsg)
+}
+
+// launch starts the application's process and begins monitoring it, recording
+// the provided audit message once the process is running.
+// The manager's lock must be held while calling it.
+func (m *Manager) launch(app *Application, user *userctx.User, logger *logrus.Entry, auditMsg string) error {
+	name := app.Config.Name
+
+	cmd := exec.Command(app.Config.Path, app.Config.Args...)
 	cmd.Env = append(os.Environ(), app.Config.Env...)
 
 	// Set working directory if specified
@@ -142,86 +349,248 @@ func (m *Manager) StartApp(ctx context.Context, name string) error {
 		cmd.Dir = app.Config.WorkingDir
 	}
 
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		cancel()
-		return fmt.Errorf("failed to create stdout pipe: %w", err)
-	}
+	var probe *readinessProbe
+	if app.Config.ReadyWhen != "" {
+		pattern, err := regexp.Compile(app.Config.ReadyWhen)
+		if err != nil {
+			return fmt.Errorf("invalid ready_when pattern: %w", err)
+		}
 
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		cancel()
-		return fmt.Errorf("failed to create stderr pipe: %w", err)
+		probe = &readinessProbe{pattern: pattern}
 	}
 
-	if err := cmd.Start(); err != nil {
-		cancel()
+	// The process' output is written to FIFOs in the data directory, which outlive tailon, if
+	// there is one. Otherwise it is copied into our own pipes so that cmd.Wait only returns once
+	// everything the process wrote has been delivered to the log collectors.
+	output, writers := m.newOutputCapture(name, logger)
+	cmd.Stdout = writers[0]
+	cmd.Stderr = writers[1]
+	cmd.WaitDelay = outputDrainTimeout
+	configureProcess(cmd, app.Config)
+
+	err := cmd.Start()
+	output.started()
+	if err != nil {
+		output.abandon()
 		return fmt.Errorf("failed to start application: %w", err)
 	}
 
 	// Update application state and user tracking
 	now := time.Now()
-	app.cmd = cmd
-	app.cancel = cancel
+	app.run++
+	app.exited = make(chan struct{})
+	app.ready = make(chan struct{})
+	app.process = cmd.Process
+	app.stopping = nil
+	app.startFailure = ""
+	app.LeftoverProcesses = nil
 	app.State = StateRunning
 	app.PID = cmd.Process.Pid
 	app.StateChangedBy = user
 	app.StateChangedAt = &now
+	app.startedAt = now
+	app.stopRequested = false
 	app.LastExitCode = 0 // Reset exit code when starting
+	app.LastExitSignal = ""
+	app.LastCoreDumped = false
+	app.LastSignalSentByTailon = false
+	app.Health = nil
+	app.recordRunStart(user)
+
+	// Everything which is logged from now on belongs to the new run
+	app.logMux.Lock()
+	app.logRun = app.run
+	app.logMux.Unlock()
+
+	// The process' start time identifies it if it needs to be adopted after tailon restarts
+	app.processStart, _ = processStartTime(app.PID)
+	m.saveState()
 
 	// Add audit log entry
-	m.addAuditLog(app, user, "Started application")
+	m.addAuditLog(app, user, auditMsg)
+
+	// Applications with a ready_when pattern are starting until they print it
+	if probe != nil {
+		run := app.run
+		probe.run = run
+		app.State = StateStarting
+		app.readyTimer = time.AfterFunc(app.readyTimeout(), func() {
+			m.readyTimeoutExpired(app, run)
+		})
+	} else {
+		close(app.ready)
+	}
+
+	if app.Config.HealthCheck != nil {
+		app.Health = &HealthState{Status: HealthStarting}
+		go m.monitorHealth(app, app.run, app.exited, now)
+	}
 
 	// Start log collection
-	go m.collectLogs(name, stdout, "stdout")
-	go m.collectLogs(name, stderr, "stderr")
+	app.output = output
+	app.detached = false
+	output.collect(m, probe, newLogParser(app.Config))
 
 	// Monitor process
-	go func() {
-		// Capture user for the goroutine closure
-		currentUser := user
-
-		var exitCode int
-		if err := cmd.Wait(); err != nil {
-			logger.WithField("app", name).WithError(err).Warn("Application exited with error")
-			// Extract exit code from error if possible
-			if exitError, ok := err.(*exec.ExitError); ok {
-				exitCode = exitError.ExitCode()
-			} else {
-				exitCode = 1 // Default to 1 for other errors
-			}
+	go m.monitor(app, app.run, cmd, output, user, logger)
+
+	logger.WithField("app", name).WithField("pid", app.PID).Info("Application started")
+	return nil
+}
+
+// processExit describes how an application's process exited
+type processExit struct {
+	code int
+	// The signal which terminated the process, if any, in which case its code is -1
+	signal     string
+	coreDumped bool
+	// Whether tailon sent the signal which terminated the process
+	sentByTailon bool
+	// Whether how the process exited is unknown, as it was adopted from an earlier instance
+	// of tailon and so couldn't be waited on (in which case its code is -1)
+	unknown bool
+}
+
+// String describes the exit for the application's audit log
+func (e processExit) String() string {
+	if e.unknown {
+		return "exited (how is unknown, as it was started before tailon restarted)"
+	}
+
+	if e.signal == "" {
+		return fmt.Sprintf("exited with code %d", e.code)
+	}
+
+	var details []string
+	if e.sentByTailon {
+		details = append(details, "sent by tailon")
+	}
+
+	if e.coreDumped {
+		details = append(details, "core dumped")
+	}
+
+	if len(details) == 0 {
+		return fmt.Sprintf("was terminated by %s", e.signal)
+	}
+
+	return fmt.Sprintf("was terminated by %s (%s)", e.signal, strings.Join(details, ", "))
+}
+
+// monitor waits for the application's process to exit, records how it exited and
+// applies the application's restart policy.
+func (m *Manager) monitor(app *Application, run uint64, cmd *exec.Cmd, output *outputCapture, user *userctx.User, logger *logrus.Entry) {
+	name := app.Config.Name
+
+	var exit processExit
+	if err := cmd.Wait(); err != nil {
+		logger.WithField("app", name).WithError(err).Warn("Application exited with error")
+		// Extract the exit status from the process state if possible (this also covers
+		// processes whose children held their output open past outputDrainTimeout)
+		if cmd.ProcessState != nil {
+			exit.code = cmd.ProcessState.ExitCode()
+			exit.signal, exit.coreDumped = terminationSignal(cmd.ProcessState)
 		} else {
-			exitCode = 0 // Successful exit
+			exit.code = 1 // Default to 1 for other errors
 		}
+	}
+
+	// Make sure all of the process' output has been recorded before we report its exit
+	output.flush()
+
+	m.processExited(app, run, cmd.Process.Pid, exit, user, logger)
+}
+
+// processExited records that the run's process has exited and applies the application's
+// restart policy.
+func (m *Manager) processExited(app *Application, run uint64, pid int, exit processExit, user *userctx.User, logger *logrus.Entry) {
+	name := app.Config.Name
 
-		m.mux.Lock()
-		now := time.Now()
-		app.State = StateNotRunning
-		app.PID = 0
-		app.cmd = nil
-		app.cancel = nil
-		app.StateChangedBy = currentUser
-		app.StateChangedAt = &now
-		app.LastExitCode = exitCode
+	// Child processes which are still running in the application's process group were left behind
+	var leftovers []ProcessInfo
+	if app.Config.UseProcessGroup() {
+		leftovers, _ = processGroupMembers(pid)
+	}
+
+	m.mux.Lock()
+	// Processes which were left running when tailon shut down are adopted by the next instance
+	if app.run != run || app.detached {
 		m.mux.Unlock()
+		return
+	}
 
-		// Add audit log for process exit
-		auditMsg := fmt.Sprintf("Application process exited with code %d", exitCode)
-		m.addAuditLog(app, currentUser, auditMsg)
+	// Signals which were sent while stopping the application are told apart from those
+	// which came from elsewhere, such as the kernel's OOM killer or a segfault
+	exit.sentByTailon = exit.signal != "" && app.stopping != nil && app.stopping.signals[exit.signal]
 
-		logger.WithField("app", name).WithField("exit_code", exitCode).Info("Application stopped")
-	}()
+	now := time.Now()
+	app.State = StateNotRunning
+	app.PID = 0
+	app.process = nil
+	app.StateChangedBy = user
+	app.StateChangedAt = &now
+	app.LastExitCode = exit.code
+	app.LastExitSignal = exit.signal
+	app.LastCoreDumped = exit.coreDumped
+	app.LastSignalSentByTailon = exit.sentByTailon
+	app.LeftoverProcesses = leftovers
+	app.Health = nil
+	app.cancelReadyTimer()
+	app.recordRunExit(run, exit, now)
+	m.saveState()
+
+	// Add audit log for process exit
+	m.addAuditLog(app, user, fmt.Sprintf("Application process %s", exit))
+
+	if len(leftovers) > 0 {
+		m.addAuditLog(app, user, fmt.Sprintf("Processes left running after exit: %s", describeProcesses(leftovers)))
+		logger.WithField("app", name).WithField("processes", describeProcesses(leftovers)).Warn("Application left processes running after it exited")
+	}
 
-	logger.WithField("app", name).WithField("pid", app.PID).Info("Application started")
-	return nil
+	exited := app.exited
+
+	// An application which failed to become ready is not restarted until a user starts it
+	if app.startFailure != "" {
+		m.fail(app, app.startFailure)
+	}
+
+	delay, restart := m.scheduleRestart(app, exit, now.Sub(app.startedAt))
+	attempt := app.RestartCount
+	if restart {
+		m.addAuditLog(app, user, fmt.Sprintf("Restarting application in %s (attempt %d)", delay, attempt))
+	}
+
+	if app.restartRequested {
+		app.restartRequested = false
+		if !m.shuttingDown {
+			m.relaunch(app, logger)
+		}
+	}
+	m.mux.Unlock()
+
+	close(exited)
+
+	logger.WithFields(logrus.Fields{
+		"app":       name,
+		"exit_code": exit.code,
+		"signal":    exit.signal,
+	}).Info("Application stopped")
+
+	if restart {
+		logger.WithField("app", name).WithField("delay", delay).WithField("attempt", attempt).Info("Scheduled application restart")
+	}
 }
 
+// StopApp gracefully stops the application, after stopping any applications which depend on it
 func (m *Manager) StopApp(ctx context.Context, name string) error {
-	return m.stopApp(ctx, name, false)
+	_, err := m.stopWithDependents(ctx, name, false)
+	return err
 }
 
+// ForceStopApp kills the application, after killing any applications which depend on it
 func (m *Manager) ForceStopApp(ctx context.Context, name string) error {
-	return m.stopApp(ctx, name, true)
+	_, err := m.stopWithDependents(ctx, name, true)
+	return err
 }
 
 func (m *Manager) stopApp(ctx context.Context, name string, force bool) error {
@@ -233,14 +602,19 @@ func (m *Manager) stopApp(ctx context.Context, name string, force bool) error {
 		return fmt.Errorf("application %s not found", name)
 	}
 
-	if !app.IsRunning() {
-		return fmt.Errorf("application %s is not running", name)
-	}
-
 	// Get user from context
 	user := userctx.FromContext(ctx)
 	logger := userctx.GetLoggerFromContext(ctx)
 
+	if app.State == StateBackoff {
+		return m.cancelPendingRestart(app, user, logger)
+	}
+
+	// An application which is already stopping can still be forcibly stopped
+	if !app.IsRunning() && !(force && app.State == StateStopping) {
+		return fmt.Errorf("application %s is not running", name)
+	}
+
 	// Log the stop event
 	action := "stop"
 	details := ""
@@ -259,36 +633,10 @@ func (m *Manager) stopApp(ctx context.Context, name string, force bool) error {
 		"event":   event,
 	}).Info("User stopped application")
 
-	// Set state to stopping and record who initiated the stop
-	now := time.Now()
-	app.State = StateStopping
-	app.StateChangedBy = user
-	app.StateChangedAt = &now
-
-	// Add audit log entry
-	auditMsg := fmt.Sprintf("Stopped application (%s)", details)
-	m.addAuditLog(app, user, auditMsg)
-
-	if force {
-		// Force stop with SIGKILL on Unix or TerminateProcess on Windows
-		if app.cmd != nil && app.cmd.Process != nil {
-			if err := app.cmd.Process.Kill(); err != nil {
-				logger.WithField("app", name).WithError(err).Warn("Failed to force kill application")
-			}
-		}
-	} else {
-		// Graceful stop - use different approaches for different platforms
-		if app.cmd != nil && app.cmd.Process != nil {
-			if err := m.gracefulStop(app.cmd.Process, app.Config.StopSignal); err != nil {
-				logger.WithField("app", name).WithError(err).Warn("Failed to gracefully stop application")
-			}
-		}
-	}
+	// A user's stop takes precedence over any restart which was in progress
+	app.restartRequested = false
 
-	// Also cancel the context as a backup
-	if app.cancel != nil {
-		app.cancel()
-	}
+	m.beginStop(app, user, logger, force, fmt.Sprintf("Stopped application (%s)", details))
 
 	return nil
 }
@@ -306,19 +654,33 @@ func (m *Manager) GetLogs(name string) ([]LogLine, error) {
 	defer app.logMux.RUnlock()
 
 	// Return a copy of the logs
-	logs := make([]LogLine, len(app.logs))
-	copy(logs, app.logs)
-	return logs, nil
+	return app.logs.snapshot(), nil
 }
 
-func (m *Manager) collectLogs(appName string, reader io.Reader, source string) {
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		line := scanner.Text()
-		logLine := LogLine{
-			Timestamp: time.Now(),
-			Message:   line,
-			Source:    source,
+func (m *Manager) collectLogs(appName string, reader io.Reader, source string, probe *readinessProbe, parser *logParser) {
+	m.mux.RLock()
+	maxLength := m.apps[appName].Config.MaxLogLineLength
+	m.mux.RUnlock()
+
+	lines := newLineReader(reader, maxLength)
+	warned := false
+	for {
+		line, err := lines.next()
+		if err != nil {
+			// The output is closed when tailon stops collecting it, leaving the process running
+			if err != io.EOF && !errors.Is(err, os.ErrClosed) {
+				m.warnOutput(appName, fmt.Sprintf("Failed to read %s, discarding the rest of its output: %v", source, err))
+
+				// The output must still be drained so that the application isn't blocked writing it
+				io.Copy(io.Discard, reader)
+			}
+
+			return
+		}
+
+		if line.split && !warned {
+			warned = true
+			m.warnOutput(appName, fmt.Sprintf("Split a line from %s which was longer than %d bytes into several log lines", source, lines.reader.Size()))
 		}
 
 		// Safely add log line by looking up the app each time
@@ -327,11 +689,46 @@ func (m *Manager) collectLogs(appName string, reader io.Reader, source string) {
 		m.mux.RUnlock()
 
 		if app != nil {
+			// Secrets are removed before the line is kept anywhere
+			message, redacted := app.redactor.redact(line.text)
+			if redacted > 0 {
+				app.redacted.Add(uint64(redacted))
+			}
+
+			logLine := LogLine{
+				Timestamp: time.Now(),
+				Message:   message,
+				Source:    source,
+				Continued: line.continued,
+			}
+
+			// Only whole lines can be parsed
+			if !line.split && !line.continued {
+				parser.parse(&logLine)
+			}
+
+			// Escape sequences are handled once the line's message has been found
+			applyANSIMode(app.Config.ANSI, &logLine)
+
 			m.addLogLine(app, logLine)
+
+			if probe.observe(line.text) {
+				m.markReady(app, probe.run)
+			}
 		}
 	}
 }
 
+// warnOutput records a problem with an application's output in its logs
+func (m *Manager) warnOutput(appName string, message string) {
+	m.mux.RLock()
+	app := m.apps[appName]
+	m.mux.RUnlock()
+
+	logrus.WithField("app", appName).Warn(message)
+	m.addAuditLog(app, userctx.System(), message)
+}
+
 // addLogLine adds a log line to the application's log buffer
 func (m *Manager) addLogLine(app *Application, logLine LogLine) {
 	if app == nil {
@@ -339,12 +736,24 @@ func (m *Manager) addLogLine(app *Application, logLine LogLine) {
 	}
 
 	app.logMux.Lock()
-	app.logs = append(app.logs, logLine)
-	if len(app.logs) > maxLogLines {
-		// Remove oldest logs to maintain circular buffer
-		copy(app.logs, app.logs[1:])
-		app.logs = app.logs[:maxLogLines]
+	app.lastSeq++
+	logLine.Seq = app.lastSeq
+	logLine.Run = app.logRun
+	app.logs.push(logLine)
+
+	// Lines are persisted while holding the lock so that they are written in order
+	if app.store != nil {
+		err := app.store.write(logLine)
+		if err != nil && !app.storeFailing {
+			logrus.WithField("app", app.Config.Name).WithError(err).Warn("Failed to persist application logs")
+		} else if err == nil && app.storeFailing {
+			logrus.WithField("app", app.Config.Name).Info("Resumed persisting application logs")
+		}
+		app.storeFailing = err != nil
 	}
+
+	app.publish(logLine)
+	app.forward(logLine)
 	app.logMux.Unlock()
 }
 
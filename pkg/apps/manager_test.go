@@ -233,13 +233,13 @@ func TestManagerStopSignalConfiguration(t *testing.T) {
 		{
 			Name:       "sigterm-app",
 			Path:       "/bin/sh",
-			Args:       []string{"-c", "trap 'echo got SIGTERM; exit' TERM; sleep 10"},
+			Args:       []string{"-c", "trap 'echo got SIGTERM; exit' TERM; sleep 10 & wait"},
 			StopSignal: "SIGTERM",
 		},
 		{
 			Name:       "sigint-app",
 			Path:       "/bin/sh",
-			Args:       []string{"-c", "trap 'echo got SIGINT; exit' INT; sleep 10"},
+			Args:       []string{"-c", "trap 'echo got SIGINT; exit' INT; sleep 10 & wait"},
 			StopSignal: "SIGINT",
 		},
 	}
@@ -302,7 +302,9 @@ func TestApplicationStates(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Wait for process to exit
-	time.Sleep(200 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	assert.NoError(t, manager.WaitForExit(ctx, "state-test"))
 
 	app, err = manager.GetApp("state-test")
 	assert.NoError(t, err)
@@ -522,3 +524,20 @@ func TestLogSourceTypes(t *testing.T) {
 	assert.True(t, foundStderr, "Should find stderr message")
 	assert.True(t, foundAudit, "Should find audit message")
 }
+
+func TestProcessExitString(t *testing.T) {
+	tests := []struct {
+		exit     processExit
+		expected string
+	}{
+		{processExit{code: 3}, "exited with code 3"},
+		{processExit{code: -1, signal: "SIGKILL"}, "was terminated by SIGKILL"},
+		{processExit{code: -1, signal: "SIGTERM", sentByTailon: true}, "was terminated by SIGTERM (sent by tailon)"},
+		{processExit{code: -1, signal: "SIGABRT", coreDumped: true}, "was terminated by SIGABRT (core dumped)"},
+		{processExit{code: -1, signal: "SIGQUIT", sentByTailon: true, coreDumped: true}, "was terminated by SIGQUIT (sent by tailon, core dumped)"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.exit.String())
+	}
+}
@@ -3,16 +3,45 @@
 package apps
 
 import (
+	"fmt"
 	"os"
+	"os/exec"
 	"syscall"
 
+	"github.com/sierrasoftworks/tailon/pkg/config"
 	"github.com/sirupsen/logrus"
 )
 
+// configureProcess prepares the command before it is started, placing it in its own
+// process group (unless disabled) so that signals can be delivered to its children too.
+func configureProcess(cmd *exec.Cmd, cfg config.ApplicationConfig) {
+	if cfg.UseProcessGroup() {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	}
+}
+
 // gracefulStop attempts to gracefully stop a process using Unix signals
-func (m *Manager) gracefulStop(process *os.Process, signalName string) error {
+func (m *Manager) gracefulStop(process *os.Process, signalName string, group bool) error {
 	// On Unix-like systems, use traditional signal handling
 	sig := parseStopSignal(signalName)
+	return signalProcess(process, sig.(syscall.Signal), group)
+}
+
+// forceStop kills a process (and its process group, if it has one) with SIGKILL
+func (m *Manager) forceStop(process *os.Process, group bool) error {
+	return signalProcess(process, syscall.SIGKILL, group)
+}
+
+// signalProcess sends a signal to the process' entire process group, falling back to
+// the process itself if the group no longer exists.
+func signalProcess(process *os.Process, sig syscall.Signal, group bool) error {
+	if group {
+		// A negative PID addresses every process in the process group led by the process
+		if err := syscall.Kill(-process.Pid, sig); err != syscall.ESRCH {
+			return err
+		}
+	}
+
 	return process.Signal(sig)
 }
 
@@ -40,15 +69,61 @@ func parseStopSignal(signalName string) os.Signal {
 	}
 }
 
+// stopSignalName returns the name of the signal which parseStopSignal picks for the signal name
+func stopSignalName(signalName string) string {
+	switch signalName {
+	case "SIGINT", "SIGTERM", "SIGQUIT", "SIGKILL", "SIGHUP":
+		return signalName
+	default:
+		return "SIGINT"
+	}
+}
+
 // getPlatformStopDetails returns platform-specific details for stop operations
 func getPlatformStopDetails(force bool, signalName string) string {
 	if force {
 		return "Force stopping application"
 	}
-	
+
 	signal := signalName
 	if signal == "" {
 		signal = "SIGINT"
 	}
 	return "Gracefully stopping application (" + signal + ")"
 }
+
+// signalNames are the names of the signals which commonly end a process
+var signalNames = map[syscall.Signal]string{
+	syscall.SIGHUP:  "SIGHUP",
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGQUIT: "SIGQUIT",
+	syscall.SIGILL:  "SIGILL",
+	syscall.SIGTRAP: "SIGTRAP",
+	syscall.SIGABRT: "SIGABRT",
+	syscall.SIGBUS:  "SIGBUS",
+	syscall.SIGFPE:  "SIGFPE",
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGUSR1: "SIGUSR1",
+	syscall.SIGSEGV: "SIGSEGV",
+	syscall.SIGUSR2: "SIGUSR2",
+	syscall.SIGPIPE: "SIGPIPE",
+	syscall.SIGALRM: "SIGALRM",
+	syscall.SIGTERM: "SIGTERM",
+	syscall.SIGXCPU: "SIGXCPU",
+	syscall.SIGXFSZ: "SIGXFSZ",
+}
+
+// terminationSignal returns the name of the signal which killed the process and whether it
+// dumped core, or an empty string if it exited normally
+func terminationSignal(state *os.ProcessState) (string, bool) {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return "", false
+	}
+
+	if name, ok := signalNames[status.Signal()]; ok {
+		return name, status.CoreDump()
+	}
+
+	return fmt.Sprintf("signal %d", int(status.Signal())), status.CoreDump()
+}
@@ -3,9 +3,12 @@
 package apps
 
 import (
+	"context"
 	"testing"
 
+	"github.com/sierrasoftworks/tailon/pkg/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseStopSignal(t *testing.T) {
@@ -29,3 +32,48 @@ func TestParseStopSignal(t *testing.T) {
 		})
 	}
 }
+
+func TestStopSignalName(t *testing.T) {
+	assert.Equal(t, "SIGINT", stopSignalName(""))
+	assert.Equal(t, "SIGTERM", stopSignalName("SIGTERM"))
+	assert.Equal(t, "SIGINT", stopSignalName("INVALID"))
+}
+
+func TestSignalTermination(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		// Core dumps are disabled so that the outcome doesn't depend on the system's limits
+		{Name: "segfault", Path: "/bin/sh", Args: []string{"-c", "ulimit -c 0; kill -SEGV $$"}},
+		{Name: "stopped", Path: "/bin/sh", Args: []string{"-c", "exec sleep 10"}, StopSignal: "SIGTERM"},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "segfault"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "segfault"))
+
+	app, err := manager.GetApp("segfault")
+	require.NoError(t, err)
+	assert.Equal(t, -1, app.LastExitCode)
+	assert.Equal(t, "SIGSEGV", app.LastExitSignal)
+	assert.False(t, app.LastCoreDumped)
+	assert.False(t, app.LastSignalSentByTailon, "tailon didn't send the signal")
+	assert.True(t, findAuditLog(t, manager, "segfault", "Application process was terminated by SIGSEGV"))
+
+	require.NoError(t, manager.StartApp(context.Background(), "stopped"))
+	require.NoError(t, manager.StopApp(context.Background(), "stopped"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "stopped"))
+
+	app, err = manager.GetApp("stopped")
+	require.NoError(t, err)
+	assert.Equal(t, "SIGTERM", app.LastExitSignal)
+	assert.True(t, app.LastSignalSentByTailon)
+	assert.True(t, findAuditLog(t, manager, "stopped", "Application process was terminated by SIGTERM (sent by tailon)"))
+
+	// Starting the application again clears how its last process exited
+	require.NoError(t, manager.StartApp(context.Background(), "stopped"))
+	defer manager.ForceStopApp(context.Background(), "stopped")
+
+	app, err = manager.GetApp("stopped")
+	require.NoError(t, err)
+	assert.Empty(t, app.LastExitSignal)
+	assert.False(t, app.LastSignalSentByTailon)
+}
@@ -4,33 +4,32 @@ package apps
 
 import (
 	"os"
+	"os/exec"
 
+	"github.com/sierrasoftworks/tailon/pkg/config"
 	"github.com/sirupsen/logrus"
 )
 
+// configureProcess prepares the command before it is started. Process groups are
+// not supported on Windows, so there is nothing to configure.
+func configureProcess(cmd *exec.Cmd, cfg config.ApplicationConfig) {}
+
 // gracefulStop attempts to gracefully stop a process on Windows
 // Windows has limited signal support compared to Unix systems
-func (m *Manager) gracefulStop(process *os.Process, signalName string) error {
-	// On Windows, we can't send arbitrary signals like on Unix
-	// For graceful stop, we rely on the context cancellation and hope the process
-	// handles it properly. If not, we may need to use more aggressive methods.
-	
-	// For Windows, there's limited signal support. The main options are:
-	// 1. Let context cancellation handle it (best case)
-	// 2. Use process.Kill() (forceful)
-	
-	// Since we're trying to be graceful, we'll give the process a chance
-	// to clean up through context cancellation, which should already be happening
-	// at this point. If the application doesn't respond within a reasonable time,
-	// the monitoring goroutine will detect the exit.
-	
-	// Note: On Windows, we could also try to send a CTRL_C_EVENT or CTRL_BREAK_EVENT
-	// but that requires more complex implementation and process group management.
-	// For now, we rely on context cancellation which should work for most Go applications
-	// and other well-behaved processes.
-	
+func (m *Manager) gracefulStop(process *os.Process, signalName string, group bool) error {
+	// On Windows, we can't send arbitrary signals like on Unix, and sending a
+	// CTRL_C_EVENT or CTRL_BREAK_EVENT requires the process to have been started
+	// in its own console process group.
+	//
+	// Since there is no graceful alternative available to us, we terminate the
+	// process directly rather than waiting for the stop timeout to expire.
 	logrus.WithField("app_signal", signalName).Debug("Graceful stop initiated on Windows")
-	return nil
+	return process.Kill()
+}
+
+// forceStop terminates the process (process groups are not supported on Windows)
+func (m *Manager) forceStop(process *os.Process, group bool) error {
+	return process.Kill()
 }
 
 // getPlatformStopDetails returns platform-specific details for stop operations
@@ -40,3 +39,13 @@ func getPlatformStopDetails(force bool, signalName string) string {
 	}
 	return "Gracefully stopping application"
 }
+
+// terminationSignal returns an empty string, as processes are not killed by signals on Windows
+func terminationSignal(state *os.ProcessState) (string, bool) {
+	return "", false
+}
+
+// stopSignalName returns an empty string, as processes are stopped without signals on Windows
+func stopSignalName(signalName string) string {
+	return ""
+}
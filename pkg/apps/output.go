@@ -0,0 +1,235 @@
+package apps
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// outputDirName is the directory in the data directory which holds the FIFOs that applications
+// write their output to
+const outputDirName = "output"
+
+// outputSources are the names of the streams which an application's output is collected from
+var outputSources = [2]string{"stdout", "stderr"}
+
+// outputCapture collects the output which an application's process writes to stdout and stderr.
+// The output is either written to pipes, which are closed when tailon exits, or to FIFOs in the
+// data directory which outlive tailon. The process holds both ends of each FIFO open, so it isn't
+// sent SIGPIPE while tailon isn't reading them (it blocks once the FIFO is full instead), and the
+// next instance of tailon reattaches to them and reads whatever the process wrote in the meantime.
+type outputCapture struct {
+	readers [2]io.ReadCloser
+	// The writers which the process is given as its stdout and stderr
+	writers [2]io.WriteCloser
+	// The directory which holds the FIFOs the output is written to, if it outlives tailon
+	dir  string
+	name string
+	done sync.WaitGroup
+}
+
+// outputDir is the directory which applications' output FIFOs are kept in, if there is a data
+// directory. The manager's lock must be held while calling it.
+func (m *Manager) outputDir() string {
+	if m.statePath == "" {
+		return ""
+	}
+
+	return filepath.Join(filepath.Dir(m.statePath), outputDirName)
+}
+
+// newOutputCapture prepares to collect the output of a process which is about to be started,
+// returning the writers which it should be given as its stdout and stderr. FIFOs are used if
+// there is a data directory, falling back to pipes if they can't be created.
+// The manager's lock must be held while calling it.
+func (m *Manager) newOutputCapture(name string, logger *logrus.Entry) (*outputCapture, [2]io.Writer) {
+	if dir := m.outputDir(); dir != "" && fifoOutputSupported {
+		output, err := newFIFOCapture(dir, name)
+		if err == nil {
+			return output, [2]io.Writer{output.writers[0], output.writers[1]}
+		}
+
+		logger.WithField("app", name).WithError(err).Warn("Failed to create output FIFOs, the application will be stopped when tailon stops")
+	}
+
+	stdout, stdoutWriter := io.Pipe()
+	stderr, stderrWriter := io.Pipe()
+	return &outputCapture{
+		readers: [2]io.ReadCloser{stdout, stderr},
+		writers: [2]io.WriteCloser{stdoutWriter, stderrWriter},
+		name:    name,
+	}, [2]io.Writer{stdoutWriter, stderrWriter}
+}
+
+// newFIFOCapture creates the application's output FIFOs in the directory and opens them
+func newFIFOCapture(dir, name string) (*outputCapture, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	output := &outputCapture{dir: dir, name: name}
+	for i, source := range outputSources {
+		path := outputPath(dir, name, source)
+
+		// Any FIFO left behind by an earlier run is replaced, as nothing can be read from it
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			output.abandon()
+			return nil, fmt.Errorf("failed to remove old output FIFO: %w", err)
+		}
+
+		if err := createFIFO(path); err != nil {
+			output.abandon()
+			return nil, fmt.Errorf("failed to create output FIFO: %w", err)
+		}
+
+		// The process holds the FIFO open for reading too, so that writing to it never fails
+		writer, err := os.OpenFile(path, os.O_RDWR, 0)
+		if err != nil {
+			output.abandon()
+			return nil, fmt.Errorf("failed to open output FIFO: %w", err)
+		}
+		output.writers[i] = writer
+		enlargeFIFO(writer)
+
+		reader, err := openFIFOReader(path)
+		if err != nil {
+			output.abandon()
+			return nil, err
+		}
+		output.readers[i] = reader
+	}
+
+	return output, nil
+}
+
+// reattachOutput returns a capture which collects the output of an adopted process from the
+// FIFOs in the directory, as long as the process was started with them
+func reattachOutput(dir, name string) (*outputCapture, error) {
+	output := &outputCapture{dir: dir, name: name}
+	for i, source := range outputSources {
+		path := outputPath(dir, name, source)
+		if info, err := os.Stat(path); err != nil || info.Mode()&os.ModeNamedPipe == 0 {
+			output.close()
+			return nil, fmt.Errorf("the process' %s was not written to a FIFO", source)
+		}
+
+		reader, err := openFIFOReader(path)
+		if err != nil {
+			output.close()
+			return nil, err
+		}
+		output.readers[i] = reader
+	}
+
+	return output, nil
+}
+
+// persistent returns whether the output is written to FIFOs, which outlive tailon
+func (c *outputCapture) persistent() bool {
+	return c.dir != ""
+}
+
+// outputPath is the path of the FIFO in the directory which the application's process writes
+// the source to
+func outputPath(dir, name, source string) string {
+	return filepath.Join(dir, fmt.Sprintf("%s.%s", name, source))
+}
+
+// started closes tailon's copies of the FIFOs which the process writes to once it has been
+// started, so that reading them ends once the process (and any children it shared them with)
+// has exited
+func (c *outputCapture) started() {
+	if c.persistent() {
+		c.closeWriters()
+	}
+}
+
+// abandon closes the output of a process which couldn't be started
+func (c *outputCapture) abandon() {
+	c.closeWriters()
+	c.close()
+	c.remove()
+}
+
+// collect starts collecting the output into the application's logs
+func (c *outputCapture) collect(m *Manager, probe *readinessProbe, parser *logParser) {
+	c.done.Add(len(c.readers))
+	for i, reader := range c.readers {
+		go func() {
+			defer c.done.Done()
+			m.collectLogs(c.name, reader, outputSources[i], probe, parser)
+		}()
+	}
+}
+
+// flush waits for all of the output to be collected once the process has exited, then removes
+// any FIFOs it was written to. As orphaned child processes may hold FIFOs open, this gives up
+// waiting for them after outputDrainTimeout.
+func (c *outputCapture) flush() {
+	if !c.persistent() {
+		// cmd.Wait has already waited for the pipes to be drained
+		c.closeWriters()
+		c.done.Wait()
+		return
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		c.done.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(outputDrainTimeout):
+		c.detach()
+	}
+
+	c.remove()
+}
+
+// detach stops collecting the output, which the process can keep writing to the FIFOs until
+// another instance of tailon reattaches to them. Any partial line which hasn't been collected
+// yet is lost.
+func (c *outputCapture) detach() {
+	c.close()
+	c.done.Wait()
+}
+
+// closeWriters closes tailon's end of the pipes or FIFOs which the process writes to
+func (c *outputCapture) closeWriters() {
+	for i, writer := range c.writers {
+		if writer != nil {
+			writer.Close()
+			c.writers[i] = nil
+		}
+	}
+}
+
+// close closes the readers, which stops the output from being collected
+func (c *outputCapture) close() {
+	for _, reader := range c.readers {
+		if reader != nil {
+			reader.Close()
+		}
+	}
+}
+
+// remove removes the FIFOs which the output was written to, once nothing writes to them
+func (c *outputCapture) remove() {
+	if c.persistent() {
+		removeOutput(c.dir, c.name)
+	}
+}
+
+// removeOutput removes the application's output FIFOs from the directory
+func removeOutput(dir, name string) {
+	for _, source := range outputSources {
+		os.Remove(outputPath(dir, name, source))
+	}
+}
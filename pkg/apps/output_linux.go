@@ -0,0 +1,42 @@
+//go:build linux
+
+package apps
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fifoOutputSupported is whether applications' output can be written to FIFOs
+const fifoOutputSupported = true
+
+// fifoPipeSize is the size which output FIFOs are enlarged to, so that the application can keep
+// writing for longer while tailon isn't reading them. Linux limits unprivileged processes to
+// pipes of this size by default.
+const fifoPipeSize = 1024 * 1024
+
+// fcntlSetPipeSize is Linux's F_SETPIPE_SZ fcntl command, which the syscall package doesn't define
+const fcntlSetPipeSize = 1031
+
+// createFIFO creates a FIFO at the path which only tailon's user may read
+func createFIFO(path string) error {
+	return syscall.Mkfifo(path, 0o600)
+}
+
+// enlargeFIFO grows the FIFO's buffer, which is only a performance improvement, so any failure
+// to do so is ignored
+func enlargeFIFO(file *os.File) {
+	syscall.Syscall(syscall.SYS_FCNTL, file.Fd(), fcntlSetPipeSize, fifoPipeSize)
+}
+
+// openFIFOReader opens the FIFO for reading without waiting for a writer, which would block
+// forever if the process which should be writing to it has exited
+func openFIFOReader(path string) (*os.File, error) {
+	reader, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output FIFO: %w", err)
+	}
+
+	return reader, nil
+}
@@ -0,0 +1,28 @@
+//go:build !linux
+
+package apps
+
+import (
+	"errors"
+	"os"
+)
+
+// errFIFOUnsupported is returned on platforms other than Linux, where adopted processes can't be
+// told apart from later processes with the same PID, so their output isn't kept either
+var errFIFOUnsupported = errors.New("writing output to FIFOs is not supported on this platform")
+
+// fifoOutputSupported is whether applications' output can be written to FIFOs
+const fifoOutputSupported = false
+
+// createFIFO is only supported on Linux
+func createFIFO(path string) error {
+	return errFIFOUnsupported
+}
+
+// enlargeFIFO is only supported on Linux
+func enlargeFIFO(file *os.File) {}
+
+// openFIFOReader is only supported on Linux
+func openFIFOReader(path string) (*os.File, error) {
+	return nil, errFIFOUnsupported
+}
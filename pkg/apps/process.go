@@ -0,0 +1,71 @@
+package apps
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProcessInfo describes an operating system process related to an application
+type ProcessInfo struct {
+	PID     int    `json:"pid"`
+	PPID    int    `json:"ppid"`
+	PGID    int    `json:"pgid"`
+	Command string `json:"command"`
+
+	// When the process started, in clock ticks after the system booted (where known)
+	startTime uint64
+}
+
+func (p ProcessInfo) String() string {
+	return fmt.Sprintf("%d (%s)", p.PID, p.Command)
+}
+
+// processTree returns the process with the given PID along with all of its descendants
+func processTree(pid int) ([]ProcessInfo, error) {
+	processes, err := listProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]ProcessInfo)
+	var tree []ProcessInfo
+	for _, process := range processes {
+		children[process.PPID] = append(children[process.PPID], process)
+		if process.PID == pid {
+			tree = append(tree, process)
+		}
+	}
+
+	// Walk the tree breadth first, starting from the root process (if it still exists)
+	for i := 0; i < len(tree); i++ {
+		tree = append(tree, children[tree[i].PID]...)
+	}
+
+	return tree, nil
+}
+
+// processGroupMembers returns all of the processes which belong to the given process group
+func processGroupMembers(pgid int) ([]ProcessInfo, error) {
+	processes, err := listProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	var members []ProcessInfo
+	for _, process := range processes {
+		if process.PGID == pgid {
+			members = append(members, process)
+		}
+	}
+
+	return members, nil
+}
+
+func describeProcesses(processes []ProcessInfo) string {
+	descriptions := make([]string, 0, len(processes))
+	for _, process := range processes {
+		descriptions = append(descriptions, process.String())
+	}
+
+	return strings.Join(descriptions, ", ")
+}
@@ -0,0 +1,115 @@
+//go:build linux
+
+package apps
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// listProcesses returns information about every process visible in /proc
+func listProcesses() ([]ProcessInfo, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	processes := make([]ProcessInfo, 0, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		// Processes may exit while we are reading them, so we skip any we can't read
+		data, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+
+		process, state, err := parseProcStat(pid, string(data))
+		if err != nil {
+			continue
+		}
+
+		// Zombie and dead processes have already exited, they just haven't been reaped yet
+		if state == "Z" || state == "X" {
+			continue
+		}
+
+		processes = append(processes, process)
+	}
+
+	return processes, nil
+}
+
+// parseProcStat parses the contents of /proc/<pid>/stat (returning the process' state
+// alongside its details), which looks like
+// "1234 (command name) S 1 1234 ...". The command name may itself contain
+// spaces and parentheses, so we split on the last closing parenthesis.
+// The process' start time is only read if the stat includes it.
+func parseProcStat(pid int, stat string) (ProcessInfo, string, error) {
+	open := strings.IndexByte(stat, '(')
+	end := strings.LastIndexByte(stat, ')')
+	if open < 0 || end < open {
+		return ProcessInfo{}, "", fmt.Errorf("malformed stat for process %d", pid)
+	}
+
+	// Fields following the command: state, ppid, pgrp, ...
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 3 {
+		return ProcessInfo{}, "", fmt.Errorf("malformed stat for process %d", pid)
+	}
+
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return ProcessInfo{}, "", fmt.Errorf("malformed parent PID for process %d: %w", pid, err)
+	}
+
+	pgid, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return ProcessInfo{}, "", fmt.Errorf("malformed process group for process %d: %w", pid, err)
+	}
+
+	process := ProcessInfo{
+		PID:     pid,
+		PPID:    ppid,
+		PGID:    pgid,
+		Command: stat[open+1 : end],
+	}
+
+	// The start time is the 22nd field of the stat, counting the PID and command
+	if len(fields) >= 20 {
+		startTime, err := strconv.ParseUint(fields[19], 10, 64)
+		if err != nil {
+			return ProcessInfo{}, "", fmt.Errorf("malformed start time for process %d: %w", pid, err)
+		}
+		process.startTime = startTime
+	}
+
+	return process, fields[0], nil
+}
+
+// processStartTime returns when the process started, in clock ticks after the system booted,
+// which tells it apart from any later process which is given the same PID. An error is
+// returned if the process is not running.
+func processStartTime(pid int) (uint64, error) {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, fmt.Errorf("process %d is not running: %w", pid, err)
+	}
+
+	process, state, err := parseProcStat(pid, string(data))
+	if err != nil {
+		return 0, err
+	}
+
+	if state == "Z" || state == "X" {
+		return 0, fmt.Errorf("process %d has exited", pid)
+	}
+
+	return process.startTime, nil
+}
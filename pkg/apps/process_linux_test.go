@@ -0,0 +1,128 @@
+//go:build linux
+
+package apps
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProcStat(t *testing.T) {
+	process, state, err := parseProcStat(1234, "1234 (my (odd) app) S 1 1200 1200 0 -1 4194560")
+	require.NoError(t, err)
+	assert.Equal(t, ProcessInfo{PID: 1234, PPID: 1, PGID: 1200, Command: "my (odd) app"}, process)
+	assert.Equal(t, "S", state)
+
+	process, _, err = parseProcStat(1234, "1234 (app) S 1 1200 1200 0 -1 4194560 100 0 0 0 5 3 0 0 20 0 1 0 98765 1000")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(98765), process.startTime)
+
+	_, _, err = parseProcStat(1234, "garbage")
+	assert.Error(t, err)
+}
+
+func TestProcessTree(t *testing.T) {
+	tree, err := processTree(os.Getpid())
+	require.NoError(t, err)
+	require.NotEmpty(t, tree)
+	assert.Equal(t, os.Getpid(), tree[0].PID)
+}
+
+func TestProcessGroupStop(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name: "wrapper",
+			Path: "/bin/sh",
+			Args: []string{"-c", "sleep 30 & sleep 30 & wait"},
+			// Background jobs in non-interactive shells ignore SIGINT
+			StopSignal: "SIGTERM",
+		},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "wrapper"))
+
+	// Wait for the shell to spawn its children
+	var app *Application
+	require.Eventually(t, func() bool {
+		app, _ = manager.GetApp("wrapper")
+		return len(app.Processes) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	pid := app.PID
+	pgid, err := syscall.Getpgid(pid)
+	require.NoError(t, err)
+	assert.Equal(t, pid, pgid, "application should lead its own process group")
+
+	require.NoError(t, manager.StopApp(context.Background(), "wrapper"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, manager.WaitForExit(ctx, "wrapper"))
+
+	// The children should have received the stop signal too
+	members, err := processGroupMembers(pid)
+	require.NoError(t, err)
+	assert.Empty(t, members)
+
+	app, err = manager.GetApp("wrapper")
+	require.NoError(t, err)
+	assert.Empty(t, app.LeftoverProcesses)
+}
+
+func TestProcessGroupLeftovers(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name: "leaky",
+			Path: "/bin/sh",
+			Args: []string{"-c", "trap '' INT; (trap '' INT; exec sleep 30) & sleep 0.2; exit 0"},
+		},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "leaky"))
+
+	require.Eventually(t, func() bool {
+		app, _ := manager.GetApp("leaky")
+		return app.State == StateNotRunning
+	}, 2*time.Second, 10*time.Millisecond)
+
+	app, err := manager.GetApp("leaky")
+	require.NoError(t, err)
+	require.Len(t, app.LeftoverProcesses, 1)
+	assert.Equal(t, "sleep", app.LeftoverProcesses[0].Command)
+	assert.True(t, findAuditLog(t, manager, "leaky", "Processes left running after exit"))
+
+	syscall.Kill(app.LeftoverProcesses[0].PID, syscall.SIGKILL)
+}
+
+func TestProcessGroupOptOut(t *testing.T) {
+	disabled := false
+	configs := []config.ApplicationConfig{
+		{
+			Name:         "shared-group",
+			Path:         "/bin/sh",
+			Args:         []string{"-c", "sleep 10 & wait"},
+			ProcessGroup: &disabled,
+		},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "shared-group"))
+
+	app, err := manager.GetApp("shared-group")
+	require.NoError(t, err)
+
+	pgid, err := syscall.Getpgid(app.PID)
+	require.NoError(t, err)
+	assert.Equal(t, syscall.Getpgrp(), pgid, "application should share tailon's process group")
+
+	require.NoError(t, manager.ForceStopApp(context.Background(), "shared-group"))
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package apps
+
+import "errors"
+
+// listProcesses is only supported on Linux, where process information is available from /proc
+func listProcesses() ([]ProcessInfo, error) {
+	return nil, errors.New("listing processes is not supported on this platform")
+}
+
+// processStartTime is only supported on Linux, so processes cannot be told apart from later
+// processes which are given the same PID on other platforms
+func processStartTime(pid int) (uint64, error) {
+	return 0, errors.New("reading process start times is not supported on this platform")
+}
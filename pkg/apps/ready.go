@@ -0,0 +1,139 @@
+package apps
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sierrasoftworks/tailon/pkg/userctx"
+)
+
+// defaultReadyTimeout is how long an application with a ready_when pattern has to
+// become ready before its start is considered to have failed.
+const defaultReadyTimeout = time.Minute
+
+// readinessProbe watches a run of an application's output for the line which
+// indicates that it is ready.
+type readinessProbe struct {
+	pattern *regexp.Regexp
+	run     uint64
+	matched atomic.Bool
+}
+
+// observe returns true the first time a line matching the probe's pattern is seen
+func (p *readinessProbe) observe(line string) bool {
+	if p == nil || p.matched.Load() || !p.pattern.MatchString(line) {
+		return false
+	}
+
+	return p.matched.CompareAndSwap(false, true)
+}
+
+func (a *Application) readyTimeout() time.Duration {
+	if a.Config.ReadyTimeout > 0 {
+		return a.Config.ReadyTimeout.Duration()
+	}
+
+	return defaultReadyTimeout
+}
+
+// markReady moves the given run of the application from the starting state to the
+// running state once it has printed its ready_when pattern.
+func (m *Manager) markReady(app *Application, run uint64) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if app.run != run || app.State != StateStarting {
+		return
+	}
+
+	app.cancelReadyTimer()
+
+	now := time.Now()
+	app.State = StateRunning
+	app.StateChangedAt = &now
+	close(app.ready)
+
+	took := now.Sub(app.startedAt).Round(time.Millisecond)
+	m.addAuditLog(app, app.StateChangedBy, fmt.Sprintf("Application is ready after %s", took))
+	logrus.WithField("app", app.Config.Name).WithField("took", took).Info("Application is ready")
+}
+
+// readyTimeoutExpired stops the given run of the application if it has not yet become
+// ready, marking its start as failed.
+func (m *Manager) readyTimeoutExpired(app *Application, run uint64) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if app.run != run || app.State != StateStarting {
+		return
+	}
+
+	app.readyTimer = nil
+
+	logger := logrus.WithField("app", app.Config.Name)
+	logger.Warn("Application did not become ready in time")
+
+	// The application is placed in the failed state by its monitor once it has exited
+	app.startFailure = fmt.Sprintf("application did not become ready within %s", app.readyTimeout())
+	m.beginStop(app, userctx.System(), logger, false, fmt.Sprintf("Stopping application which did not become ready within %s", app.readyTimeout()))
+}
+
+// cancelReadyTimer stops the timer which fails the application's start if it does not
+// become ready in time. The manager's lock must be held while calling it.
+func (a *Application) cancelReadyTimer() {
+	if a.readyTimer != nil {
+		a.readyTimer.Stop()
+		a.readyTimer = nil
+	}
+}
+
+// WaitReady blocks until the application's current process has become ready, returning
+// an error if it exits first or the context is done.
+func (m *Manager) WaitReady(ctx context.Context, name string) error {
+	m.mux.RLock()
+	app, exists := m.apps[name]
+	if !exists {
+		m.mux.RUnlock()
+		return fmt.Errorf("application %s not found", name)
+	}
+
+	if !app.IsRunning() {
+		state := app.State
+		m.mux.RUnlock()
+		return fmt.Errorf("application %s is not running (%s)", name, state)
+	}
+
+	ready, exited := app.ready, app.exited
+	m.mux.RUnlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-exited:
+		// The application may have become ready before it exited
+		select {
+		case <-ready:
+			return nil
+		default:
+		}
+
+		m.mux.RLock()
+		defer m.mux.RUnlock()
+		if app.Failure != nil {
+			return fmt.Errorf("application %s failed to become ready: %s", name, app.Failure.Reason)
+		}
+
+		if app.LastExitSignal != "" {
+			return fmt.Errorf("application %s was terminated by %s before it became ready", name, app.LastExitSignal)
+		}
+
+		return fmt.Errorf("application %s exited with code %d before it became ready", name, app.LastExitCode)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,109 @@
+package apps
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadinessProbe(t *testing.T) {
+	probe := &readinessProbe{pattern: regexp.MustCompile(`listening on :\d+`)}
+
+	assert.False(t, probe.observe("starting up"))
+	assert.True(t, probe.observe("listening on :8080"))
+	assert.False(t, probe.observe("listening on :8080"), "the probe should only match once")
+
+	var missing *readinessProbe
+	assert.False(t, missing.observe("listening on :8080"))
+}
+
+func TestReadyWhen(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:      "server",
+			Path:      "/bin/sh",
+			Args:      []string{"-c", "sleep 0.2; echo 'listening on :8080'; sleep 10"},
+			ReadyWhen: `listening on :\d+`,
+		},
+	}
+
+	manager := NewManager(configs)
+	defer manager.Shutdown(context.Background())
+
+	require.NoError(t, manager.StartApp(context.Background(), "server"))
+
+	app, _ := manager.GetApp("server")
+	assert.Equal(t, StateStarting, app.State)
+	assert.True(t, app.IsRunning())
+
+	// Starting an application which is still starting is not allowed
+	assert.Error(t, manager.StartApp(context.Background(), "server"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, manager.WaitReady(ctx, "server"))
+
+	app, _ = manager.GetApp("server")
+	assert.Equal(t, StateRunning, app.State)
+	assert.True(t, findAuditLog(t, manager, "server", "Application is ready after"))
+}
+
+func TestReadyTimeout(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:         "silent",
+			Path:         "/bin/sh",
+			Args:         []string{"-c", "echo 'still warming up' >&2; sleep 10 & wait"},
+			ReadyWhen:    "ready",
+			ReadyTimeout: config.Duration(100 * time.Millisecond),
+			StopSignal:   "SIGTERM",
+		},
+	}
+
+	manager := NewManager(configs)
+	defer manager.Shutdown(context.Background())
+
+	require.NoError(t, manager.StartApp(context.Background(), "silent"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err := manager.WaitReady(ctx, "silent")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did not become ready within 100ms")
+
+	app, _ := manager.GetApp("silent")
+	assert.Equal(t, StateFailed, app.State)
+	require.NotNil(t, app.Failure)
+	assert.Contains(t, app.Failure.StderrTail, "still warming up")
+
+	// Starting the application again clears the failure
+	require.NoError(t, manager.StartApp(context.Background(), "silent"))
+	app, _ = manager.GetApp("silent")
+	assert.Equal(t, StateStarting, app.State)
+	assert.Nil(t, app.Failure)
+}
+
+func TestWaitReadyExited(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:      "crashing",
+			Path:      "/bin/sh",
+			Args:      []string{"-c", "exit 3"},
+			ReadyWhen: "ready",
+		},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "crashing"))
+
+	err := manager.WaitReady(context.Background(), "crashing")
+	require.Error(t, err)
+
+	// The application may already have exited by the time we started waiting
+	assert.Regexp(t, "exited with code 3 before it became ready|is not running", err.Error())
+}
@@ -0,0 +1,125 @@
+package apps
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+// redactedText replaces secrets which are removed from an application's logs
+const redactedText = "[REDACTED]"
+
+// redactor removes secrets from an application's log lines
+type redactor struct {
+	// Literal secrets, longest first so that a secret which contains another is removed whole
+	secrets  []string
+	patterns []*regexp.Regexp
+}
+
+// newRedactor creates a redactor for the application's secrets, or returns nil if it has none
+func newRedactor(cfg config.ApplicationConfig) *redactor {
+	r := &redactor{}
+	for _, name := range cfg.Redaction.SecretEnv {
+		if value := secretEnvValue(cfg.Env, name); value != "" {
+			r.secrets = append(r.secrets, value)
+		}
+	}
+
+	sort.Slice(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+
+	for _, pattern := range cfg.Redaction.Patterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			logrus.WithField("app", cfg.Name).WithError(err).Warn("Ignoring invalid redaction pattern")
+			continue
+		}
+
+		r.patterns = append(r.patterns, compiled)
+	}
+
+	if len(r.secrets) == 0 && len(r.patterns) == 0 {
+		return nil
+	}
+
+	return r
+}
+
+// secretEnvValue returns the value of the environment variable which the application is run
+// with, where later entries in its env override earlier ones and tailon's own environment.
+func secretEnvValue(env []string, name string) string {
+	for i := len(env) - 1; i >= 0; i-- {
+		if key, value, found := strings.Cut(env[i], "="); found && key == name {
+			return value
+		}
+	}
+
+	return os.Getenv(name)
+}
+
+// redact returns the text with its secrets replaced, along with the number which were replaced.
+// A nil redactor returns the text unchanged.
+func (r *redactor) redact(text string) (string, int) {
+	if r == nil {
+		return text, 0
+	}
+
+	count := 0
+	for _, secret := range r.secrets {
+		if n := strings.Count(text, secret); n > 0 {
+			text = strings.ReplaceAll(text, secret, redactedText)
+			count += n
+		}
+	}
+
+	for _, pattern := range r.patterns {
+		var n int
+		text, n = redactPattern(pattern, text)
+		count += n
+	}
+
+	return text, count
+}
+
+// redactPattern replaces the pattern's matches, or the text matched by its capture groups if
+// it has any, returning the number of replacements.
+func redactPattern(pattern *regexp.Regexp, text string) (string, int) {
+	matches := pattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text, 0
+	}
+
+	var b strings.Builder
+	count, last := 0, 0
+	for _, match := range matches {
+		spans := [][]int{match[:2]}
+		if len(match) > 2 {
+			spans = spans[:0]
+			for i := 2; i+1 < len(match); i += 2 {
+				spans = append(spans, match[i:i+2])
+			}
+		}
+
+		for _, span := range spans {
+			// Skip groups which didn't participate in the match, or which are nested
+			// within a group which has already been redacted
+			if span[0] < 0 || span[0] < last || span[0] == span[1] {
+				continue
+			}
+
+			b.WriteString(text[last:span[0]])
+			b.WriteString(redactedText)
+			last = span[1]
+			count++
+		}
+	}
+
+	b.WriteString(text[last:])
+	return b.String(), count
+}
@@ -0,0 +1,108 @@
+package apps
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+func TestRedactorNone(t *testing.T) {
+	assert.Nil(t, newRedactor(config.ApplicationConfig{}))
+
+	// Secrets which aren't set can't be redacted
+	assert.Nil(t, newRedactor(config.ApplicationConfig{
+		Redaction: config.RedactionConfig{SecretEnv: []string{"TAILON_TEST_UNSET_SECRET"}},
+	}))
+
+	// A nil redactor leaves text as it is
+	var r *redactor
+	text, count := r.redact("token=abc")
+	assert.Equal(t, "token=abc", text)
+	assert.Zero(t, count)
+}
+
+func TestRedactorSecretEnv(t *testing.T) {
+	t.Setenv("TAILON_TEST_INHERITED_SECRET", "inherited-value")
+
+	r := newRedactor(config.ApplicationConfig{
+		Env: []string{"API_TOKEN=old-token", "OTHER=visible", "API_TOKEN=s3cr3t-token", "SHORT=s3cr3t"},
+		Redaction: config.RedactionConfig{
+			SecretEnv: []string{"API_TOKEN", "SHORT", "TAILON_TEST_INHERITED_SECRET"},
+		},
+	})
+	require.NotNil(t, r)
+
+	text, count := r.redact("token s3cr3t-token, again s3cr3t-token, short s3cr3t, inherited inherited-value, old old-token, other visible")
+	assert.Equal(t, "token [REDACTED], again [REDACTED], short [REDACTED], inherited [REDACTED], old old-token, other visible", text)
+	assert.Equal(t, 4, count)
+}
+
+func TestRedactorPatterns(t *testing.T) {
+	r := newRedactor(config.ApplicationConfig{
+		Redaction: config.RedactionConfig{
+			Patterns: []string{
+				`password=(\S+)`,
+				`Bearer [A-Za-z0-9.]+`,
+				`key=(\w+):(\w+)`,
+			},
+		},
+	})
+	require.NotNil(t, r)
+
+	tests := []struct {
+		input    string
+		expected string
+		count    int
+	}{
+		{"nothing to see here", "nothing to see here", 0},
+		{"login password=hunter2 user=bob", "login password=[REDACTED] user=bob", 1},
+		{"password=a password=b", "password=[REDACTED] password=[REDACTED]", 2},
+		{"Authorization: Bearer abc.def", "Authorization: [REDACTED]", 1},
+		{"key=id:secret", "key=[REDACTED]:[REDACTED]", 2},
+	}
+
+	for _, tt := range tests {
+		text, count := r.redact(tt.input)
+		assert.Equal(t, tt.expected, text, tt.input)
+		assert.Equal(t, tt.count, count, tt.input)
+	}
+}
+
+func TestCollectLogsRedacted(t *testing.T) {
+	manager := NewManager([]config.ApplicationConfig{
+		{
+			Name: "leaky",
+			Path: "/bin/sh",
+			Args: []string{"-c", `echo "token is $API_TOKEN"; echo "password=hunter2" >&2`},
+			Env:  []string{"API_TOKEN=s3cr3t-token"},
+			Redaction: config.RedactionConfig{
+				SecretEnv: []string{"API_TOKEN"},
+				Patterns:  []string{`password=(\S+)`},
+			},
+		},
+	})
+
+	require.NoError(t, manager.StartApp(context.Background(), "leaky"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "leaky"))
+
+	logs, err := manager.GetLogs("leaky")
+	require.NoError(t, err)
+
+	var output []string
+	for _, line := range logs {
+		assert.False(t, strings.Contains(line.Message, "s3cr3t") || strings.Contains(line.Message, "hunter2"), line.Message)
+		if line.Source != "audit" {
+			output = append(output, line.Message)
+		}
+	}
+	assert.ElementsMatch(t, []string{"token is [REDACTED]", "password=[REDACTED]"}, output)
+
+	app, err := manager.GetApp("leaky")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), app.Redactions)
+}
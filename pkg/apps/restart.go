@@ -0,0 +1,149 @@
+package apps
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/sierrasoftworks/tailon/pkg/userctx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultRestartInitialDelay = time.Second
+	defaultRestartMaxDelay     = time.Minute
+	defaultRestartMultiplier   = 2.0
+)
+
+// restartDelay calculates the exponential backoff delay before the given restart attempt (starting at 1)
+func restartDelay(backoff config.RestartBackoffConfig, attempt int) time.Duration {
+	initial := backoff.InitialDelay.Duration()
+	if initial <= 0 {
+		initial = defaultRestartInitialDelay
+	}
+
+	maxDelay := backoff.MaxDelay.Duration()
+	if maxDelay <= 0 {
+		maxDelay = defaultRestartMaxDelay
+	}
+
+	multiplier := backoff.Multiplier
+	if multiplier < 1 {
+		multiplier = defaultRestartMultiplier
+	}
+
+	delay := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if delay > float64(maxDelay) {
+		return maxDelay
+	}
+
+	return time.Duration(delay)
+}
+
+// scheduleRestart applies the application's restart policy after its process has exited,
+// moving it into the backoff state and arming a timer if it should be restarted. Processes
+// which exited in an unknown way are only restarted by the policies which restart the
+// application however it exits. Nothing is
+// restarted once tailon is shutting down, as Shutdown has already cancelled pending restarts.
+// The manager's lock must be held while calling it.
+func (m *Manager) scheduleRestart(app *Application, exit processExit, ranFor time.Duration) (time.Duration, bool) {
+	policy := app.Config.RestartPolicy
+	if app.stopRequested || m.shuttingDown || !policy.ShouldRestart(exit.code) || (exit.unknown && !policy.ShouldRestartUnknownExit()) {
+		app.RestartCount = 0
+		return 0, false
+	}
+
+	now := time.Now()
+	app.recordExit(now, exit.code)
+	if app.isCrashLooping(now) {
+		m.fail(app, fmt.Sprintf("crash loop detected: exited %d times within %s", app.crashLoopMaxExits(), app.crashLoopWindow()))
+		return 0, false
+	}
+
+	// An application which stayed up for longer than the maximum backoff delay is
+	// considered healthy again, so it starts from the initial delay.
+	if ranFor >= restartDelay(app.Config.RestartBackoff, math.MaxInt32) {
+		app.RestartCount = 0
+	}
+
+	if maxRetries := app.Config.RestartBackoff.MaxRetries; maxRetries > 0 && app.RestartCount >= maxRetries {
+		m.addAuditLog(app, app.StateChangedBy, fmt.Sprintf("Giving up on restarting application after %d attempts", app.RestartCount))
+		return 0, false
+	}
+
+	app.RestartCount++
+	delay := restartDelay(app.Config.RestartBackoff, app.RestartCount)
+	next := now.Add(delay)
+
+	app.State = StateBackoff
+	app.NextRestartAt = &next
+
+	name, run := app.Config.Name, app.run
+	app.restartTimer = time.AfterFunc(delay, func() {
+		m.restart(name, run)
+	})
+
+	return delay, true
+}
+
+// restart is invoked by the backoff timer to start an application again
+func (m *Manager) restart(name string, run uint64) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	// The timer may have fired while Shutdown was cancelling it
+	app, exists := m.apps[name]
+	if !exists || app.State != StateBackoff || app.run != run || m.shuttingDown {
+		return
+	}
+
+	app.restartTimer = nil
+	app.NextRestartAt = nil
+
+	// Restarts are attributed to whoever started the application originally
+	user := app.StateChangedBy
+	logger := logrus.WithField("app", name)
+
+	auditMsg := fmt.Sprintf("Restarted application (attempt %d)", app.RestartCount)
+	if err := m.launch(app, user, logger, auditMsg); err != nil {
+		logger.WithError(err).Warn("Failed to restart application")
+		m.addAuditLog(app, user, fmt.Sprintf("Failed to restart application: %v", err))
+
+		// A failed launch counts as a failed run for the purposes of the restart policy
+		now := time.Now()
+		app.State = StateNotRunning
+		app.StateChangedAt = &now
+		if delay, ok := m.scheduleRestart(app, processExit{code: -1}, 0); ok {
+			logger.WithField("delay", delay).Info("Scheduled application restart")
+		}
+	}
+}
+
+// cancelRestart stops any pending automatic restart of the application.
+// The manager's lock must be held while calling it.
+func (a *Application) cancelRestart() {
+	if a.restartTimer != nil {
+		a.restartTimer.Stop()
+		a.restartTimer = nil
+	}
+
+	a.NextRestartAt = nil
+}
+
+// cancelPendingRestart handles a user request to stop an application which is
+// currently waiting to be restarted. The manager's lock must be held while calling it.
+func (m *Manager) cancelPendingRestart(app *Application, user *userctx.User, logger *logrus.Entry) error {
+	app.cancelRestart()
+
+	now := time.Now()
+	app.State = StateNotRunning
+	app.RestartCount = 0
+	app.StateChangedBy = user
+	app.StateChangedAt = &now
+
+	m.addAuditLog(app, user, "Cancelled pending restart")
+	logger.WithField("app", app.Config.Name).Info("Cancelled pending application restart")
+
+	return nil
+}
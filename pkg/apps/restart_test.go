@@ -0,0 +1,180 @@
+package apps
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartDelay(t *testing.T) {
+	backoff := config.RestartBackoffConfig{
+		InitialDelay: config.Duration(100 * time.Millisecond),
+		MaxDelay:     config.Duration(time.Second),
+		Multiplier:   3,
+	}
+
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 300 * time.Millisecond},
+		{3, 900 * time.Millisecond},
+		{4, time.Second},
+		{10, time.Second},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, restartDelay(backoff, test.attempt), "attempt %d", test.attempt)
+	}
+
+	// Defaults are used when no backoff is configured
+	assert.Equal(t, defaultRestartInitialDelay, restartDelay(config.RestartBackoffConfig{}, 1))
+	assert.Equal(t, defaultRestartMaxDelay, restartDelay(config.RestartBackoffConfig{}, 100))
+}
+
+func TestRestartPolicyOnFailure(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:          "crashing",
+			Path:          "/bin/sh",
+			Args:          []string{"-c", "exit 3"},
+			RestartPolicy: config.RestartOnFailure,
+			RestartBackoff: config.RestartBackoffConfig{
+				InitialDelay: config.Duration(50 * time.Millisecond),
+				MaxRetries:   2,
+			},
+		},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "crashing"))
+
+	require.Eventually(t, func() bool {
+		app, _ := manager.GetApp("crashing")
+		return app.State == StateBackoff
+	}, time.Second, 10*time.Millisecond)
+
+	app, err := manager.GetApp("crashing")
+	require.NoError(t, err)
+	assert.Equal(t, 3, app.LastExitCode)
+	assert.NotNil(t, app.NextRestartAt)
+
+	// After exhausting its retries the application should remain stopped
+	require.Eventually(t, func() bool {
+		app, _ := manager.GetApp("crashing")
+		return app.State == StateNotRunning && app.RestartCount == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	app, err = manager.GetApp("crashing")
+	require.NoError(t, err)
+	assert.Nil(t, app.NextRestartAt)
+}
+
+func TestRestartPolicyOnFailureCleanExit(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:          "clean",
+			Path:          "/bin/sh",
+			Args:          []string{"-c", "exit 0"},
+			RestartPolicy: config.RestartOnFailure,
+		},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "clean"))
+
+	time.Sleep(200 * time.Millisecond)
+
+	app, err := manager.GetApp("clean")
+	require.NoError(t, err)
+	assert.Equal(t, StateNotRunning, app.State)
+	assert.Equal(t, 0, app.RestartCount)
+}
+
+func TestRestartPolicySuppressedByUserStop(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:          "always",
+			Path:          "/bin/sh",
+			Args:          []string{"-c", "sleep 10 & wait"},
+			RestartPolicy: config.RestartAlways,
+			RestartBackoff: config.RestartBackoffConfig{
+				InitialDelay: config.Duration(10 * time.Millisecond),
+			},
+		},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "always"))
+	require.NoError(t, manager.StopApp(context.Background(), "always"))
+
+	time.Sleep(200 * time.Millisecond)
+
+	app, err := manager.GetApp("always")
+	require.NoError(t, err)
+	assert.Equal(t, StateNotRunning, app.State)
+	assert.Nil(t, app.NextRestartAt)
+}
+
+func TestStopAppCancelsPendingRestart(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:          "backoff",
+			Path:          "/bin/sh",
+			Args:          []string{"-c", "exit 1"},
+			RestartPolicy: config.RestartAlways,
+			RestartBackoff: config.RestartBackoffConfig{
+				InitialDelay: config.Duration(time.Minute),
+			},
+		},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "backoff"))
+
+	require.Eventually(t, func() bool {
+		app, _ := manager.GetApp("backoff")
+		return app.State == StateBackoff
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, manager.StopApp(context.Background(), "backoff"))
+
+	app, err := manager.GetApp("backoff")
+	require.NoError(t, err)
+	assert.Equal(t, StateNotRunning, app.State)
+	assert.Nil(t, app.NextRestartAt)
+	assert.Equal(t, 0, app.RestartCount)
+
+	logs, err := manager.GetLogs("backoff")
+	require.NoError(t, err)
+	assert.Equal(t, "Anonymous: Cancelled pending restart", logs[len(logs)-1].Message)
+}
+
+func TestNoRestartsWhileShuttingDown(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:          "crashing",
+			Path:          "/bin/sh",
+			Args:          []string{"-c", "exit 1"},
+			RestartPolicy: config.RestartAlways,
+		},
+	}
+
+	manager := NewManager(configs)
+
+	// An application which exits by itself while Shutdown is stopping the others isn't restarted
+	manager.mux.Lock()
+	manager.shuttingDown = true
+	app := manager.apps["crashing"]
+	_, restart := manager.scheduleRestart(app, processExit{code: 1}, time.Second)
+	manager.mux.Unlock()
+
+	assert.False(t, restart)
+	assert.Nil(t, app.restartTimer)
+	assert.Equal(t, StateNotRunning, app.State)
+}
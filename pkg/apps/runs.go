@@ -0,0 +1,136 @@
+package apps
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/userctx"
+)
+
+// maxRunHistory is the number of each application's most recent runs which are remembered
+const maxRunHistory = 50
+
+// RunOutcome describes how one of an application's runs ended
+type RunOutcome string
+
+const (
+	// RunActive indicates that the run's process is still running
+	RunActive RunOutcome = "running"
+	// RunExited indicates that the process exited successfully of its own accord
+	RunExited RunOutcome = "exited"
+	// RunCrashed indicates that the process failed or was killed without being asked to stop
+	RunCrashed RunOutcome = "crashed"
+	// RunStopped indicates that the process exited after being asked to stop
+	RunStopped RunOutcome = "stopped"
+	// RunFailed indicates that the process was stopped because it did not become ready in time
+	RunFailed RunOutcome = "failed"
+	// RunLost indicates that how the process exited is unknown, as it was started by an earlier
+	// instance of tailon
+	RunLost RunOutcome = "lost"
+)
+
+// RunRecord describes one run of an application's process. The log lines which were
+// written during the run are tagged with its ID.
+type RunRecord struct {
+	ID        uint64        `json:"id"`
+	PID       int           `json:"pid"`
+	StartedAt time.Time     `json:"started_at"`
+	StartedBy *userctx.User `json:"started_by,omitempty"`
+	StoppedAt *time.Time    `json:"stopped_at,omitempty"`
+	// The user who asked for the process to be stopped, if it didn't exit of its own accord
+	StoppedBy *userctx.User `json:"stopped_by,omitempty"`
+	// The process' exit code, unless it was killed by a signal
+	ExitCode *int `json:"exit_code,omitempty"`
+	// The signal which killed the process (such as "SIGKILL"), if any
+	Signal string `json:"signal,omitempty"`
+	// Whether the process dumped core when the signal killed it
+	CoreDumped bool `json:"core_dumped,omitempty"`
+	// Whether tailon sent the signal which killed the process, such as when stopping it
+	SignalSentByTailon bool       `json:"signal_sent_by_tailon,omitempty"`
+	Outcome            RunOutcome `json:"outcome"`
+}
+
+// recordRunStart adds the application's current run to its history, forgetting the oldest
+// run once there are too many. The manager's lock must be held while calling it.
+func (a *Application) recordRunStart(user *userctx.User) {
+	a.runs = append(a.runs, RunRecord{
+		ID:        a.run,
+		PID:       a.PID,
+		StartedAt: a.startedAt,
+		StartedBy: user,
+		Outcome:   RunActive,
+	})
+
+	if len(a.runs) > maxRunHistory {
+		a.runs = append(a.runs[:0], a.runs[len(a.runs)-maxRunHistory:]...)
+	}
+}
+
+// runRecord returns the application's record of the run, or nil if it has been forgotten.
+// The manager's lock must be held while calling it.
+func (a *Application) runRecord(run uint64) *RunRecord {
+	for i := len(a.runs) - 1; i >= 0; i-- {
+		if a.runs[i].ID == run {
+			return &a.runs[i]
+		}
+	}
+
+	return nil
+}
+
+// recordRunStopRequest records who first asked for the application's current run to be
+// stopped. The manager's lock must be held while calling it.
+func (a *Application) recordRunStopRequest(user *userctx.User) {
+	if record := a.runRecord(a.run); record != nil && record.StoppedBy == nil {
+		record.StoppedBy = user
+	}
+}
+
+// recordRunExit records how the run's process exited, where a signal is reported in place
+// of the exit code if one killed it. The manager's lock must be held while calling it.
+func (a *Application) recordRunExit(run uint64, exit processExit, stoppedAt time.Time) {
+	record := a.runRecord(run)
+	if record == nil {
+		return
+	}
+
+	record.StoppedAt = &stoppedAt
+	switch {
+	case exit.unknown:
+		// Neither the exit code nor the signal are known
+	case exit.signal != "":
+		record.Signal = exit.signal
+		record.CoreDumped = exit.coreDumped
+		record.SignalSentByTailon = exit.sentByTailon
+	default:
+		record.ExitCode = &exit.code
+	}
+
+	switch {
+	case a.startFailure != "":
+		record.Outcome = RunFailed
+	case a.stopRequested:
+		record.Outcome = RunStopped
+	case exit.unknown:
+		record.Outcome = RunLost
+	case exit.code == 0 && exit.signal == "":
+		record.Outcome = RunExited
+	default:
+		record.Outcome = RunCrashed
+	}
+}
+
+// GetRuns returns the application's most recent runs, oldest first
+func (m *Manager) GetRuns(name string) ([]RunRecord, error) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	app, exists := m.apps[name]
+	if !exists {
+		return nil, fmt.Errorf("application %s not found", name)
+	}
+
+	runs := make([]RunRecord, len(app.runs))
+	copy(runs, app.runs)
+	return runs, nil
+}
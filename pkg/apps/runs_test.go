@@ -0,0 +1,147 @@
+package apps
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/sierrasoftworks/tailon/pkg/userctx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunHistory(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name: "runs",
+			Path: "/bin/sh",
+			// The first run waits to be stopped, while later runs exit by themselves
+			Args:       []string{"-c", "if [ -f marker ]; then echo oops; exit 3; fi; touch marker; echo started; exec sleep 10"},
+			WorkingDir: t.TempDir(),
+		},
+	}
+
+	manager := NewManager(configs)
+	alice := &userctx.User{ID: "alice", DisplayName: "Alice"}
+	bob := &userctx.User{ID: "bob", DisplayName: "Bob"}
+
+	// The first run is stopped by a user
+	require.NoError(t, manager.StartApp(userctx.WithUser(context.Background(), alice), "runs"))
+	require.Eventually(t, func() bool {
+		logs, _ := manager.GetLogs("runs")
+		return len(logs) >= 2
+	}, 2*time.Second, 10*time.Millisecond)
+	require.NoError(t, manager.StopApp(userctx.WithUser(context.Background(), bob), "runs"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "runs"))
+
+	runs, err := manager.GetRuns("runs")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+
+	first := runs[0]
+	assert.Equal(t, uint64(1), first.ID)
+	assert.NotZero(t, first.PID)
+	assert.Equal(t, "alice", first.StartedBy.ID)
+	require.NotNil(t, first.StoppedBy)
+	assert.Equal(t, "bob", first.StoppedBy.ID)
+	require.NotNil(t, first.StoppedAt)
+	assert.False(t, first.StoppedAt.Before(first.StartedAt))
+	assert.Equal(t, RunStopped, first.Outcome)
+	assert.Nil(t, first.ExitCode)
+	assert.Equal(t, "SIGINT", first.Signal)
+	assert.True(t, first.SignalSentByTailon)
+
+	// The second run exits by itself
+	require.NoError(t, manager.StartApp(userctx.WithUser(context.Background(), alice), "runs"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "runs"))
+
+	runs, err = manager.GetRuns("runs")
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+
+	second := runs[1]
+	assert.Equal(t, uint64(2), second.ID)
+	assert.Nil(t, second.StoppedBy)
+	assert.Equal(t, RunCrashed, second.Outcome)
+	require.NotNil(t, second.ExitCode)
+	assert.Equal(t, 3, *second.ExitCode)
+
+	// Each line is tagged with the run it was logged during
+	logs, err := manager.GetLogs("runs")
+	require.NoError(t, err)
+
+	messages := map[string]uint64{}
+	for _, line := range logs {
+		if line.Source == "stdout" {
+			messages[line.Message] = line.Run
+		}
+		assert.NotZero(t, line.Run, line.Message)
+	}
+	assert.Equal(t, map[string]uint64{"started": 1, "oops": 2}, messages)
+
+	_, err = manager.GetRuns("missing")
+	assert.Error(t, err)
+}
+
+func TestRunHistoryOutcomes(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{Name: "clean", Path: "/bin/sh", Args: []string{"-c", "exit 0"}},
+		{Name: "killed", Path: "/bin/sh", Args: []string{"-c", "kill -KILL $$"}},
+	}
+
+	manager := NewManager(configs)
+	for _, name := range []string{"clean", "killed"} {
+		require.NoError(t, manager.StartApp(context.Background(), name))
+		require.NoError(t, manager.WaitForExit(context.Background(), name))
+	}
+
+	runs, err := manager.GetRuns("clean")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, RunExited, runs[0].Outcome)
+	require.NotNil(t, runs[0].ExitCode)
+	assert.Equal(t, 0, *runs[0].ExitCode)
+
+	runs, err = manager.GetRuns("killed")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, RunCrashed, runs[0].Outcome)
+	assert.Equal(t, "SIGKILL", runs[0].Signal)
+	assert.False(t, runs[0].SignalSentByTailon)
+	assert.Nil(t, runs[0].ExitCode)
+}
+
+func TestRunHistoryIsBounded(t *testing.T) {
+	app := &Application{}
+	for i := 0; i < maxRunHistory+5; i++ {
+		app.run++
+		app.recordRunStart(nil)
+	}
+
+	require.Len(t, app.runs, maxRunHistory)
+	assert.Equal(t, uint64(6), app.runs[0].ID)
+	assert.Equal(t, uint64(maxRunHistory+5), app.runs[maxRunHistory-1].ID)
+}
+
+func TestRunIDsContinueFromPersistedLogs(t *testing.T) {
+	storage := &config.LogStorageConfig{Directory: t.TempDir()}
+	configs := []config.ApplicationConfig{
+		{Name: "persisted", Path: "/bin/sh", Args: []string{"-c", "echo hello"}, LogStorage: storage},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "persisted"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "persisted"))
+	manager.closeLogStores()
+
+	// A new instance of tailon doesn't reuse the run IDs found in the logs it loads
+	manager = NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "persisted"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "persisted"))
+
+	runs, err := manager.GetRuns("persisted")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, uint64(2), runs[0].ID)
+}
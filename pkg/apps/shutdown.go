@@ -0,0 +1,176 @@
+package apps
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/sierrasoftworks/tailon/pkg/userctx"
+)
+
+// shutdownKillTimeout is how long Shutdown waits for applications to exit after they
+// have been forcibly stopped.
+const shutdownKillTimeout = 5 * time.Second
+
+// ShutdownError is returned by Shutdown when one or more applications could not be stopped
+type ShutdownError struct {
+	Applications []string
+}
+
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf("failed to stop applications: %s", strings.Join(e.Applications, ", "))
+}
+
+// SetShutdownMode sets whether Shutdown stops the applications or leaves them running, so that
+// the next instance of tailon adopts them
+func (m *Manager) SetShutdownMode(mode config.ShutdownMode) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.keepRunning = mode == config.ShutdownKeepRunning
+}
+
+// Shutdown stops every running application, first gracefully using each application's
+// stop signal and then, once the context is done, forcibly. Pending restarts are cancelled
+// and no applications may be started once Shutdown has been called. If any application
+// is still running after being forcibly stopped, a ShutdownError listing them is returned.
+//
+// In the keep-running shutdown mode, applications whose output is written to FIFOs in the data
+// directory are left running instead, and tailon stops collecting their output. Only the
+// applications whose output would be lost are stopped.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	ctx = userctx.WithSystemUser(ctx)
+	logger := userctx.GetLoggerFromContext(ctx)
+
+	// Any lines logged after this point will reopen the log files
+	defer m.closeLogStores()
+	// Deliver the lines which were logged while the applications were stopping
+	defer m.closeLogSinks()
+	// Stop following log files once the applications which write them have stopped
+	defer m.closeLogFileTailers()
+
+	m.mux.Lock()
+	m.shuttingDown = true
+	keepRunning := m.keepRunning
+
+	running := make(map[string]chan struct{})
+	var detached []*outputCapture
+	for name, app := range m.apps {
+		app.cancelRestart()
+		if app.State == StateBackoff {
+			app.State = StateNotRunning
+		}
+
+		if app.process == nil {
+			continue
+		}
+
+		if keepRunning && app.stopping == nil {
+			if app.output == nil || app.output.persistent() {
+				// The process' exit is left for the next instance of tailon to record
+				app.detached = true
+				if app.output != nil {
+					detached = append(detached, app.output)
+				}
+
+				logger.WithField("app", name).WithField("pid", app.PID).Info("Leaving application running")
+				continue
+			}
+
+			logger.WithField("app", name).Warn("Stopping application, as its output would be lost if it was left running")
+		}
+
+		running[name] = app.exited
+	}
+	m.mux.Unlock()
+
+	for _, output := range detached {
+		output.detach()
+	}
+
+	if len(running) == 0 {
+		return nil
+	}
+
+	logger.WithField("apps", len(running)).Info("Stopping applications")
+
+	// Applications which are left running aren't stopped along with their dependencies
+	stop := m.StopApp
+	if keepRunning {
+		stop = func(ctx context.Context, name string) error {
+			return m.stopApp(ctx, name, false)
+		}
+	}
+
+	names := make([]string, 0, len(running))
+	for name := range running {
+		names = append(names, name)
+	}
+
+	// Applications are stopped before the applications they depend on
+	for _, name := range m.stopOrder(names) {
+		if err := stop(ctx, name); err != nil {
+			logger.WithField("app", name).WithError(err).Debug("Application was not running during shutdown")
+		}
+	}
+
+	remaining := waitForExit(ctx.Done(), running)
+	if len(remaining) == 0 {
+		return nil
+	}
+
+	for name := range remaining {
+		logger.WithField("app", name).Warn("Application did not stop gracefully, forcibly stopping it")
+		// Everything which is left is killed immediately, regardless of its dependencies
+		if err := m.stopApp(ctx, name, true); err != nil {
+			logger.WithField("app", name).WithError(err).Debug("Application was not running during forced shutdown")
+		}
+	}
+
+	timeout := time.NewTimer(shutdownKillTimeout)
+	defer timeout.Stop()
+
+	remaining = waitForExit(timeout.C, remaining)
+	if len(remaining) == 0 {
+		return nil
+	}
+
+	failed := make([]string, 0, len(remaining))
+	for name := range remaining {
+		failed = append(failed, name)
+	}
+	sort.Strings(failed)
+
+	return &ShutdownError{Applications: failed}
+}
+
+// waitForExit waits until either all of the provided applications have exited, or the
+// done channel is closed, returning the applications which are still running.
+func waitForExit[T any](done <-chan T, running map[string]chan struct{}) map[string]chan struct{} {
+	remaining := make(map[string]chan struct{}, len(running))
+	for name, exited := range running {
+		remaining[name] = exited
+	}
+
+	for name, exited := range remaining {
+		select {
+		case <-exited:
+			delete(remaining, name)
+		case <-done:
+			// Collect any other applications which have already exited
+			for name, exited := range remaining {
+				select {
+				case <-exited:
+					delete(remaining, name)
+				default:
+				}
+			}
+			return remaining
+		}
+	}
+
+	return remaining
+}
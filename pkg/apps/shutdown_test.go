@@ -0,0 +1,117 @@
+package apps
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdown(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:       "graceful",
+			Path:       "/bin/sh",
+			Args:       []string{"-c", "trap 'exit 0' TERM; while true; do sleep 0.1; done"},
+			StopSignal: "SIGTERM",
+		},
+		{
+			Name:          "backoff",
+			Path:          "/bin/sh",
+			Args:          []string{"-c", "exit 1"},
+			RestartPolicy: config.RestartAlways,
+			RestartBackoff: config.RestartBackoffConfig{
+				InitialDelay: config.Duration(time.Minute),
+			},
+		},
+		{
+			Name: "idle",
+			Path: "/bin/echo",
+		},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "graceful"))
+	require.NoError(t, manager.StartApp(context.Background(), "backoff"))
+
+	require.Eventually(t, func() bool {
+		app, _ := manager.GetApp("backoff")
+		return app.State == StateBackoff
+	}, time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, manager.Shutdown(ctx))
+
+	for name, app := range manager.GetApps() {
+		assert.Equal(t, StateNotRunning, app.State, "application %s should be stopped", name)
+		assert.Nil(t, app.NextRestartAt)
+	}
+
+	assert.True(t, findAuditLog(t, manager, "graceful", "System: Stopped application"))
+
+	// Applications cannot be started once we are shutting down
+	err := manager.StartApp(context.Background(), "idle")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "shutting down")
+}
+
+func TestShutdownEscalatesToKill(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:       "stubborn",
+			Path:       "/bin/sh",
+			Args:       []string{"-c", "trap '' TERM; while true; do sleep 0.1; done"},
+			StopSignal: "SIGTERM",
+		},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "stubborn"))
+
+	// Give the shell a moment to install its signal handlers
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, manager.Shutdown(ctx))
+
+	app, err := manager.GetApp("stubborn")
+	require.NoError(t, err)
+	assert.Equal(t, StateNotRunning, app.State)
+}
+
+func TestShutdownError(t *testing.T) {
+	err := &ShutdownError{Applications: []string{"a", "b"}}
+	assert.Equal(t, "failed to stop applications: a, b", err.Error())
+}
+
+func TestShutdownKeepRunningWithoutFIFOs(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:       "app",
+			Path:       "/bin/sh",
+			Args:       []string{"-c", "trap 'exit 0' TERM; while true; do sleep 0.1; done"},
+			StopSignal: "SIGTERM",
+		},
+	}
+
+	// Without a data directory the application's output is written to pipes, which would be
+	// closed when tailon exits, so it is stopped anyway
+	manager := NewManager(configs)
+	manager.SetShutdownMode(config.ShutdownKeepRunning)
+	require.NoError(t, manager.StartApp(context.Background(), "app"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, manager.Shutdown(ctx))
+
+	app, err := manager.GetApp("app")
+	require.NoError(t, err)
+	assert.Equal(t, StateNotRunning, app.State)
+}
@@ -0,0 +1,272 @@
+package apps
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sierrasoftworks/tailon/pkg/userctx"
+)
+
+// stateFileName is the name of the file in the data directory which the manager's state is kept in
+const stateFileName = "state.json"
+
+// adoptedPollInterval is how often adopted processes are checked to see whether they have exited,
+// as processes which weren't started by this instance of tailon can't be waited on.
+const adoptedPollInterval = 250 * time.Millisecond
+
+// managerState is the state which the manager keeps across restarts of tailon
+type managerState struct {
+	Apps map[string]appState `json:"apps"`
+}
+
+// appState is an application's persisted state, which describes its process if it is running
+type appState struct {
+	// The application's latest run, which the IDs of later runs carry on from
+	Run uint64 `json:"run"`
+	PID int    `json:"pid,omitempty"`
+	// When the process started, in clock ticks after boot, which tells it apart from any later
+	// process which is given the same PID
+	ProcessStart uint64        `json:"process_start,omitempty"`
+	StartedAt    *time.Time    `json:"started_at,omitempty"`
+	StartedBy    *userctx.User `json:"started_by,omitempty"`
+}
+
+// RestoreState persists the manager's state to the data directory from now on, so that it can
+// pick up where it left off when tailon restarts. The processes which were running when tailon
+// last stopped are adopted if they are still running, or otherwise recorded as lost.
+func (m *Manager) RestoreState(dataDir string) error {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	path := filepath.Join(dataDir, stateFileName)
+	state, err := readState(path)
+	if err != nil {
+		// The state will be replaced once it has been written again
+		logrus.WithField("file", path).WithError(err).Warn("Failed to read the state file, ignoring it")
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.statePath = path
+	for name, saved := range state.Apps {
+		app, exists := m.apps[name]
+		if !exists {
+			if saved.PID != 0 {
+				logrus.WithField("app", name).WithField("pid", saved.PID).Warn("Leaving the process of an application which is no longer configured running")
+			}
+			continue
+		}
+
+		app.run = max(app.run, saved.Run)
+		if saved.PID != 0 && app.process == nil {
+			m.adopt(app, saved)
+		}
+	}
+
+	m.saveState()
+	return nil
+}
+
+// readState reads the manager's state from the file, returning an empty state if it doesn't exist
+func readState(path string) (managerState, error) {
+	state := managerState{}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+
+		return state, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return managerState{}, fmt.Errorf("failed to decode state file: %w", err)
+	}
+
+	return state, nil
+}
+
+// saveState writes the manager's state to its state file, if it has one.
+// The manager's lock must be held while calling it.
+func (m *Manager) saveState() {
+	if m.statePath == "" {
+		return
+	}
+
+	state := managerState{Apps: make(map[string]appState, len(m.apps))}
+	for name, app := range m.apps {
+		saved := appState{Run: app.run}
+		if app.process != nil {
+			startedAt := app.startedAt
+			saved.PID = app.PID
+			saved.ProcessStart = app.processStart
+			saved.StartedAt = &startedAt
+			if record := app.runRecord(app.run); record != nil {
+				saved.StartedBy = record.StartedBy
+			}
+		}
+
+		state.Apps[name] = saved
+	}
+
+	err := writeState(m.statePath, state)
+	if err != nil && !m.stateFailing {
+		logrus.WithField("file", m.statePath).WithError(err).Warn("Failed to persist application state")
+	} else if err == nil && m.stateFailing {
+		logrus.WithField("file", m.statePath).Info("Resumed persisting application state")
+	}
+	m.stateFailing = err != nil
+}
+
+// writeState replaces the state file, without leaving it partially written if tailon stops
+func writeState(path string, state managerState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	if err := os.Rename(temp, path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+
+	return nil
+}
+
+// adopt resumes monitoring the application's process, which was started by an earlier instance
+// of tailon, if it is still running. Otherwise the run is recorded as lost.
+// The manager's lock must be held while calling it.
+func (m *Manager) adopt(app *Application, saved appState) {
+	name := app.Config.Name
+	logger := logrus.WithField("app", name).WithField("pid", saved.PID)
+
+	user := saved.StartedBy
+	if user == nil {
+		user = userctx.System()
+	}
+
+	startedAt := time.Now()
+	if saved.StartedAt != nil {
+		startedAt = *saved.StartedAt
+	}
+
+	// The run carries on where the earlier instance of tailon left off
+	app.run = saved.Run
+	app.PID = saved.PID
+	app.startedAt = startedAt
+	app.recordRunStart(saved.StartedBy)
+
+	app.logMux.Lock()
+	app.logRun = app.run
+	app.logMux.Unlock()
+
+	process, err := findProcess(saved.PID, saved.ProcessStart)
+	if err != nil {
+		now := time.Now()
+		app.PID = 0
+		app.StateChangedBy = userctx.System()
+		app.StateChangedAt = &now
+		if record := app.runRecord(app.run); record != nil {
+			record.Outcome = RunLost
+		}
+
+		// Nothing is left to write to the process' output FIFOs
+		if dir := m.outputDir(); dir != "" {
+			removeOutput(dir, name)
+		}
+
+		m.addAuditLog(app, userctx.System(), fmt.Sprintf("Application process %d was lost while tailon was not running", saved.PID))
+		logger.WithError(err).Warn("Application process was lost while tailon was not running")
+
+		// The process wasn't stopped by a user, so policies which restart the application however
+		// it exits start it again, while how it exited is unknown to the others
+		if delay, ok := m.scheduleRestart(app, processExit{code: -1, unknown: true}, 0); ok {
+			m.addAuditLog(app, userctx.System(), fmt.Sprintf("Restarting application in %s (attempt %d)", delay, app.RestartCount))
+		}
+		return
+	}
+
+	app.process = process
+	app.processStart = saved.ProcessStart
+	app.exited = make(chan struct{})
+	app.ready = make(chan struct{})
+	close(app.ready)
+	app.stopping = nil
+	app.stopRequested = false
+	app.startFailure = ""
+	app.State = StateRunning
+	app.StateChangedBy = user
+	app.StateChangedAt = &startedAt
+	app.output = nil
+	app.detached = false
+
+	m.addAuditLog(app, userctx.System(), fmt.Sprintf("Adopted application process %d, which was started before tailon restarted", saved.PID))
+	logger.Info("Adopted application process")
+
+	// The process' output can only be collected if it was written to FIFOs in the data directory
+	if dir := m.outputDir(); dir != "" {
+		output, err := reattachOutput(dir, name)
+		if err != nil {
+			m.addAuditLog(app, userctx.System(), "The output of the adopted process is not being logged, as it was not written to FIFOs in the data directory")
+			logger.WithError(err).Warn("Failed to reattach to the adopted process' output")
+		} else {
+			app.output = output
+			output.collect(m, nil, newLogParser(app.Config))
+		}
+	}
+
+	if app.Config.HealthCheck != nil {
+		app.Health = &HealthState{Status: HealthStarting}
+		go m.monitorHealth(app, app.run, app.exited, startedAt)
+	}
+
+	go m.monitorAdopted(app, app.run, saved.PID, saved.ProcessStart, app.output, user, logger)
+}
+
+// findProcess returns the process with the PID, as long as it is the process which started at
+// the given time rather than a later process which has been given the same PID.
+func findProcess(pid int, startTime uint64) (*os.Process, error) {
+	actual, err := processStartTime(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	if actual != startTime {
+		return nil, fmt.Errorf("process %d has been replaced by another process with the same PID", pid)
+	}
+
+	return os.FindProcess(pid)
+}
+
+// monitorAdopted waits for an adopted process to exit and then applies the application's
+// restart policy. As the process isn't a child of this instance of tailon, it is polled
+// rather than waited on, and how it exited is unknown.
+func (m *Manager) monitorAdopted(app *Application, run uint64, pid int, startTime uint64, output *outputCapture, user *userctx.User, logger *logrus.Entry) {
+	ticker := time.NewTicker(adoptedPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if actual, err := processStartTime(pid); err != nil || actual != startTime {
+			break
+		}
+	}
+
+	// Make sure all of the process' output has been recorded before we report its exit
+	if output != nil {
+		output.flush()
+	}
+
+	m.processExited(app, run, pid, processExit{code: -1, unknown: true}, user, logger)
+}
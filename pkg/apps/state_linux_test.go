@@ -0,0 +1,271 @@
+//go:build linux
+
+package apps
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/sierrasoftworks/tailon/pkg/userctx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startOrphan starts a process as an earlier instance of tailon would have, returning the state
+// which that instance would have persisted for it
+func startOrphan(t *testing.T, script string) appState {
+	t.Helper()
+
+	cmd := exec.Command("/bin/sh", "-c", script)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	require.NoError(t, cmd.Start())
+
+	// The process is reaped once it exits, as it would be if it had been orphaned
+	go cmd.Wait()
+	t.Cleanup(func() { cmd.Process.Kill() })
+
+	start, err := processStartTime(cmd.Process.Pid)
+	require.NoError(t, err)
+
+	startedAt := time.Now()
+	return appState{
+		Run:          3,
+		PID:          cmd.Process.Pid,
+		ProcessStart: start,
+		StartedAt:    &startedAt,
+		StartedBy:    &userctx.User{ID: "alice", DisplayName: "Alice"},
+	}
+}
+
+func TestProcessStartTime(t *testing.T) {
+	cmd := exec.Command("/bin/sh", "-c", "exec sleep 10")
+	require.NoError(t, cmd.Start())
+
+	start, err := processStartTime(cmd.Process.Pid)
+	require.NoError(t, err)
+	assert.NotZero(t, start)
+
+	// Processes which have exited but not yet been reaped aren't running
+	require.NoError(t, cmd.Process.Kill())
+	require.Eventually(t, func() bool {
+		_, err := processStartTime(cmd.Process.Pid)
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+
+	cmd.Wait()
+	_, err = processStartTime(cmd.Process.Pid)
+	assert.Error(t, err)
+}
+
+func TestRestoreStateAdoptsProcess(t *testing.T) {
+	dir := t.TempDir()
+	saved := startOrphan(t, "exec sleep 30")
+	writeTestState(t, dir, managerState{Apps: map[string]appState{"adopted": saved}})
+
+	configs := []config.ApplicationConfig{
+		{Name: "adopted", Path: "/bin/sh", Args: []string{"-c", "exec sleep 30"}, StopSignal: "SIGTERM"},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.RestoreState(dir))
+
+	app, err := manager.GetApp("adopted")
+	require.NoError(t, err)
+	assert.Equal(t, StateRunning, app.State)
+	assert.Equal(t, saved.PID, app.PID)
+	assert.Equal(t, "alice", app.StateChangedBy.ID)
+	assert.True(t, findAuditLog(t, manager, "adopted", "Adopted application process"))
+	require.NoError(t, manager.WaitReady(context.Background(), "adopted"))
+
+	runs, err := manager.GetRuns("adopted")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, uint64(3), runs[0].ID)
+	assert.Equal(t, RunActive, runs[0].Outcome)
+	assert.Equal(t, "alice", runs[0].StartedBy.ID)
+
+	// The adopted process is still persisted, so it can be adopted again
+	assert.Equal(t, saved.PID, readTestState(t, dir).Apps["adopted"].PID)
+
+	// Adopted processes are stopped like any other
+	require.NoError(t, manager.StopApp(context.Background(), "adopted"))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, manager.WaitForExit(ctx, "adopted"))
+
+	app, err = manager.GetApp("adopted")
+	require.NoError(t, err)
+	assert.Equal(t, StateNotRunning, app.State)
+	assert.Equal(t, -1, app.LastExitCode)
+	assert.True(t, findAuditLog(t, manager, "adopted", "Application process exited (how is unknown"))
+
+	runs, err = manager.GetRuns("adopted")
+	require.NoError(t, err)
+	assert.Equal(t, RunStopped, runs[0].Outcome)
+	assert.Equal(t, appState{Run: 3}, readTestState(t, dir).Apps["adopted"])
+}
+
+func TestRestoreStateAdoptedProcessExits(t *testing.T) {
+	dir := t.TempDir()
+	saved := startOrphan(t, "sleep 0.3")
+	writeTestState(t, dir, managerState{Apps: map[string]appState{"adopted": saved}})
+
+	configs := []config.ApplicationConfig{
+		{Name: "adopted", Path: "/bin/sh", Args: []string{"-c", "sleep 0.3"}},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.RestoreState(dir))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, manager.WaitForExit(ctx, "adopted"))
+
+	runs, err := manager.GetRuns("adopted")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, RunLost, runs[0].Outcome)
+	assert.NotNil(t, runs[0].StoppedAt)
+	assert.Nil(t, runs[0].ExitCode)
+}
+
+func TestRestoreStateReusedPID(t *testing.T) {
+	dir := t.TempDir()
+	saved := startOrphan(t, "exec sleep 30")
+
+	// A different process which has been given the application's old PID isn't adopted
+	saved.ProcessStart--
+	writeTestState(t, dir, managerState{Apps: map[string]appState{"app": saved}})
+
+	manager := NewManager([]config.ApplicationConfig{{Name: "app", Path: "/bin/echo"}})
+	require.NoError(t, manager.RestoreState(dir))
+
+	app, err := manager.GetApp("app")
+	require.NoError(t, err)
+	assert.Equal(t, StateNotRunning, app.State)
+	assert.True(t, findAuditLog(t, manager, "app", "was lost while tailon was not running"))
+}
+
+func TestShutdownKeepRunning(t *testing.T) {
+	dir := t.TempDir()
+	resume := filepath.Join(dir, "resume")
+
+	// The application writes more output once tailon has been restarted
+	configs := []config.ApplicationConfig{
+		{
+			Name: "app",
+			Path: "/bin/sh",
+			Args: []string{"-c", fmt.Sprintf("echo before; while [ ! -e %s ]; do sleep 0.05; done; echo after; exec sleep 30", resume)},
+		},
+	}
+
+	hasOutput := func(manager *Manager, message string) bool {
+		logs, err := manager.GetLogs("app")
+		require.NoError(t, err)
+
+		for _, line := range logs {
+			if line.Source == "stdout" && line.Message == message {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.RestoreState(dir))
+	manager.SetShutdownMode(config.ShutdownKeepRunning)
+	require.NoError(t, manager.StartApp(context.Background(), "app"))
+
+	app, err := manager.GetApp("app")
+	require.NoError(t, err)
+	pid := app.PID
+	t.Cleanup(func() { syscall.Kill(pid, syscall.SIGKILL) })
+
+	require.Eventually(t, func() bool { return hasOutput(manager, "before") }, 2*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, manager.Shutdown(ctx))
+
+	// The process is left running, without being sent SIGPIPE once it writes more output
+	require.NoError(t, os.WriteFile(resume, nil, 0o644))
+	time.Sleep(200 * time.Millisecond)
+	assert.NoError(t, syscall.Kill(pid, 0))
+	assert.Equal(t, pid, readTestState(t, dir).Apps["app"].PID)
+
+	// The next instance of tailon adopts the process and collects the output it wrote meanwhile
+	restarted := NewManager(configs)
+	require.NoError(t, restarted.RestoreState(dir))
+
+	app, err = restarted.GetApp("app")
+	require.NoError(t, err)
+	assert.Equal(t, StateRunning, app.State)
+	assert.Equal(t, pid, app.PID)
+	require.Eventually(t, func() bool { return hasOutput(restarted, "after") }, 2*time.Second, 10*time.Millisecond)
+
+	// The FIFOs are removed once the process has exited
+	require.NoError(t, restarted.ForceStopApp(context.Background(), "app"))
+	require.NoError(t, restarted.WaitForExit(ctx, "app"))
+	assert.NoFileExists(t, filepath.Join(dir, outputDirName, "app.stdout"))
+}
+
+func TestRestoreStateAdoptedProcessExitsRestartPolicy(t *testing.T) {
+	tests := []struct {
+		policy  config.RestartPolicy
+		restart bool
+	}{
+		// How the process exited is unknown, so it may not have failed
+		{config.RestartOnFailure, false},
+		{config.RestartUnlessStopped, true},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.policy), func(t *testing.T) {
+			dir := t.TempDir()
+			saved := startOrphan(t, "sleep 0.3")
+			writeTestState(t, dir, managerState{Apps: map[string]appState{"adopted": saved}})
+
+			configs := []config.ApplicationConfig{
+				{
+					Name:          "adopted",
+					Path:          "/bin/sh",
+					Args:          []string{"-c", "exec sleep 30"},
+					RestartPolicy: test.policy,
+					RestartBackoff: config.RestartBackoffConfig{
+						InitialDelay: config.Duration(time.Minute),
+					},
+				},
+			}
+
+			manager := NewManager(configs)
+			require.NoError(t, manager.RestoreState(dir))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			require.NoError(t, manager.WaitForExit(ctx, "adopted"))
+
+			app, err := manager.GetApp("adopted")
+			require.NoError(t, err)
+			if test.restart {
+				assert.Equal(t, StateBackoff, app.State)
+				assert.Equal(t, 1, app.RestartCount)
+			} else {
+				assert.Equal(t, StateNotRunning, app.State)
+				assert.Nil(t, app.NextRestartAt)
+				assert.Zero(t, app.RestartCount)
+			}
+
+			manager.mux.Lock()
+			manager.apps["adopted"].cancelRestart()
+			manager.mux.Unlock()
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package apps
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readTestState(t *testing.T, dir string) managerState {
+	t.Helper()
+
+	state, err := readState(filepath.Join(dir, stateFileName))
+	require.NoError(t, err)
+	return state
+}
+
+func writeTestState(t *testing.T, dir string, state managerState) {
+	t.Helper()
+
+	require.NoError(t, writeState(filepath.Join(dir, stateFileName), state))
+}
+
+// exitedPID returns the PID of a process which has exited
+func exitedPID(t *testing.T) int {
+	t.Helper()
+
+	cmd := exec.Command("/bin/sh", "-c", "exit 0")
+	require.NoError(t, cmd.Run())
+	return cmd.Process.Pid
+}
+
+func TestStatePersisted(t *testing.T) {
+	dir := t.TempDir()
+	configs := []config.ApplicationConfig{
+		{Name: "sleeper", Path: "/bin/sh", Args: []string{"-c", "exec sleep 10"}},
+		{Name: "idle", Path: "/bin/echo"},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.RestoreState(dir))
+	require.NoError(t, manager.StartApp(context.Background(), "sleeper"))
+
+	app, err := manager.GetApp("sleeper")
+	require.NoError(t, err)
+
+	state := readTestState(t, dir)
+	require.Contains(t, state.Apps, "idle")
+	assert.Equal(t, appState{}, state.Apps["idle"])
+
+	saved := state.Apps["sleeper"]
+	assert.Equal(t, uint64(1), saved.Run)
+	assert.Equal(t, app.PID, saved.PID)
+	require.NotNil(t, saved.StartedAt)
+	require.NotNil(t, saved.StartedBy)
+	assert.Equal(t, "$anonymous$", saved.StartedBy.ID)
+
+	require.NoError(t, manager.StopApp(context.Background(), "sleeper"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "sleeper"))
+
+	// Only the run ID is kept once the application has stopped
+	assert.Equal(t, appState{Run: 1}, readTestState(t, dir).Apps["sleeper"])
+}
+
+func TestRestoreStateLostProcess(t *testing.T) {
+	dir := t.TempDir()
+	writeTestState(t, dir, managerState{Apps: map[string]appState{
+		"lost":    {Run: 4, PID: exitedPID(t), ProcessStart: 1},
+		"removed": {Run: 2},
+	}})
+
+	configs := []config.ApplicationConfig{
+		{Name: "lost", Path: "/bin/sh", Args: []string{"-c", "echo hello"}},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.RestoreState(dir))
+
+	app, err := manager.GetApp("lost")
+	require.NoError(t, err)
+	assert.Equal(t, StateNotRunning, app.State)
+	assert.Zero(t, app.PID)
+	assert.True(t, findAuditLog(t, manager, "lost", "was lost while tailon was not running"))
+
+	runs, err := manager.GetRuns("lost")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, uint64(4), runs[0].ID)
+	assert.Equal(t, RunLost, runs[0].Outcome)
+
+	// Applications which are no longer configured are forgotten
+	state := readTestState(t, dir)
+	assert.NotContains(t, state.Apps, "removed")
+	assert.Equal(t, appState{Run: 4}, state.Apps["lost"])
+
+	// Later runs carry on from the lost run's ID
+	require.NoError(t, manager.StartApp(context.Background(), "lost"))
+	require.NoError(t, manager.WaitForExit(context.Background(), "lost"))
+
+	runs, err = manager.GetRuns("lost")
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, uint64(5), runs[1].ID)
+}
+
+func TestRestoreStateLostProcessRestarted(t *testing.T) {
+	dir := t.TempDir()
+	writeTestState(t, dir, managerState{Apps: map[string]appState{
+		"unless-stopped": {Run: 1, PID: exitedPID(t), ProcessStart: 1},
+		"on-failure":     {Run: 1, PID: exitedPID(t), ProcessStart: 1},
+	}})
+
+	backoff := config.RestartBackoffConfig{InitialDelay: config.Duration(10 * time.Millisecond)}
+	configs := []config.ApplicationConfig{
+		{Name: "unless-stopped", Path: "/bin/sh", Args: []string{"-c", "exec sleep 10"}, RestartPolicy: config.RestartUnlessStopped, RestartBackoff: backoff},
+		{Name: "on-failure", Path: "/bin/sh", Args: []string{"-c", "exec sleep 10"}, RestartPolicy: config.RestartOnFailure, RestartBackoff: backoff},
+	}
+
+	manager := NewManager(configs)
+	defer manager.Shutdown(context.Background())
+	require.NoError(t, manager.RestoreState(dir))
+
+	// The lost process wasn't stopped by a user, so it is started again
+	require.Eventually(t, func() bool {
+		app, _ := manager.GetApp("unless-stopped")
+		return app.State == StateRunning
+	}, time.Second, 10*time.Millisecond)
+
+	// How the process exited is unknown, so it isn't known to have failed
+	app, err := manager.GetApp("on-failure")
+	require.NoError(t, err)
+	assert.Equal(t, StateNotRunning, app.State)
+}
+
+func TestRestoreStateInvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, stateFileName), []byte("not json"), 0o644))
+
+	manager := NewManager([]config.ApplicationConfig{{Name: "app", Path: "/bin/echo"}})
+	require.NoError(t, manager.RestoreState(dir))
+
+	// The invalid state is replaced
+	assert.Equal(t, appState{}, readTestState(t, dir).Apps["app"])
+}
+
+func TestRestoreStateCreatesDataDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+
+	manager := NewManager([]config.ApplicationConfig{{Name: "app", Path: "/bin/echo"}})
+	require.NoError(t, manager.RestoreState(dir))
+	assert.FileExists(t, filepath.Join(dir, stateFileName))
+}
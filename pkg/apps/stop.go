@@ -0,0 +1,165 @@
+package apps
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sierrasoftworks/tailon/pkg/userctx"
+)
+
+// defaultStopTimeout is how long an application is given to exit after being sent its
+// stop signal before it is forcibly killed.
+const defaultStopTimeout = 10 * time.Second
+
+// stopRequest tracks an in-progress stop of an application's process
+type stopRequest struct {
+	requestedAt time.Time
+	user        *userctx.User
+	killed      atomic.Bool
+	// The names of the signals which have been sent to the process (guarded by the manager's lock)
+	signals map[string]bool
+}
+
+// sentSignal records that the signal was sent to the process.
+// The manager's lock must be held while calling it.
+func (r *stopRequest) sentSignal(name string) {
+	if r.signals == nil {
+		r.signals = make(map[string]bool)
+	}
+
+	r.signals[name] = true
+}
+
+func (a *Application) stopTimeout() time.Duration {
+	if a.Config.StopTimeout > 0 {
+		return a.Config.StopTimeout.Duration()
+	}
+
+	return defaultStopTimeout
+}
+
+// beginStop moves a running application into the stopping state and signals its process,
+// arranging for it to be killed if it does not exit within its stop timeout.
+// The manager's lock must be held while calling it.
+func (m *Manager) beginStop(app *Application, user *userctx.User, logger *logrus.Entry, force bool, auditMsg string) {
+	// Set state to stopping and record who initiated the stop, which
+	// prevents the restart policy from bringing the application back.
+	now := time.Now()
+	app.stopRequested = true
+	app.State = StateStopping
+	app.StateChangedBy = user
+	app.StateChangedAt = &now
+	app.recordRunStopRequest(user)
+
+	// Add audit log entry
+	m.addAuditLog(app, user, auditMsg)
+
+	// Track the stop so that we can report how long it took, and escalate if necessary
+	req := app.stopping
+	if req == nil {
+		req = &stopRequest{requestedAt: now, user: user}
+		app.stopping = req
+		go m.awaitStop(app, req, app.exited, app.stopTimeout(), logger)
+	}
+
+	if force {
+		// Force stop with SIGKILL on Unix or TerminateProcess on Windows
+		req.killed.Store(true)
+		if app.process != nil {
+			req.sentSignal("SIGKILL")
+			if err := m.forceStop(app.process, app.Config.UseProcessGroup()); err != nil {
+				logger.WithField("app", app.Config.Name).WithError(err).Warn("Failed to force kill application")
+			}
+		}
+	} else {
+		// Graceful stop - use different approaches for different platforms
+		if app.process != nil {
+			req.sentSignal(stopSignalName(app.Config.StopSignal))
+			if err := m.gracefulStop(app.process, app.Config.StopSignal, app.Config.UseProcessGroup()); err != nil {
+				logger.WithField("app", app.Config.Name).WithError(err).Warn("Failed to gracefully stop application")
+			}
+		}
+	}
+}
+
+// WaitForExit blocks until the application's current process (if it has one) has exited,
+// or the context is done.
+func (m *Manager) WaitForExit(ctx context.Context, name string) error {
+	m.mux.RLock()
+	app, exists := m.apps[name]
+	if !exists {
+		m.mux.RUnlock()
+		return fmt.Errorf("application %s not found", name)
+	}
+
+	var exited chan struct{}
+	if app.process != nil {
+		exited = app.exited
+	}
+	m.mux.RUnlock()
+
+	if exited == nil {
+		return nil
+	}
+
+	select {
+	case <-exited:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// awaitStop waits for an application which has been asked to stop to exit, forcibly
+// killing it if it has not done so within the timeout, and records how the stop went
+// in the application's audit log.
+func (m *Manager) awaitStop(app *Application, req *stopRequest, exited <-chan struct{}, timeout time.Duration, logger *logrus.Entry) {
+	name := app.Config.Name
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	escalated := false
+	select {
+	case <-exited:
+	case <-timer.C:
+		if !req.killed.Load() {
+			escalated = true
+			req.killed.Store(true)
+
+			m.addAuditLog(app, req.user, fmt.Sprintf("Application did not stop within %s, escalating to SIGKILL", timeout))
+			logger.WithField("app", name).WithField("timeout", timeout).Warn("Application did not stop in time, killing it")
+
+			m.mux.Lock()
+			if app.stopping == req && app.process != nil {
+				req.sentSignal("SIGKILL")
+				if err := m.forceStop(app.process, app.Config.UseProcessGroup()); err != nil {
+					logger.WithField("app", name).WithError(err).Warn("Failed to kill application")
+				}
+			}
+			m.mux.Unlock()
+		}
+
+		<-exited
+	}
+
+	took := time.Since(req.requestedAt).Round(time.Millisecond)
+	switch {
+	case escalated:
+		m.addAuditLog(app, req.user, fmt.Sprintf("Application stopped after %s (escalated to SIGKILL)", took))
+	case req.killed.Load():
+		m.addAuditLog(app, req.user, fmt.Sprintf("Application stopped after %s (force stopped)", took))
+	default:
+		m.addAuditLog(app, req.user, fmt.Sprintf("Application stopped gracefully after %s", took))
+	}
+
+	logger.WithFields(logrus.Fields{
+		"app":       name,
+		"duration":  took,
+		"escalated": escalated,
+	}).Info("Application stop completed")
+}
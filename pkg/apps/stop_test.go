@@ -0,0 +1,127 @@
+package apps
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findAuditLog(t *testing.T, manager *Manager, name, contains string) bool {
+	t.Helper()
+
+	logs, err := manager.GetLogs(name)
+	require.NoError(t, err)
+
+	for _, log := range logs {
+		if log.Source == "audit" && strings.Contains(log.Message, contains) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestStopTimeoutGraceful(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:        "graceful",
+			Path:        "/bin/sh",
+			Args:        []string{"-c", "trap 'sleep 0.2; exit 0' TERM; while true; do sleep 0.05; done"},
+			StopSignal:  "SIGTERM",
+			StopTimeout: config.Duration(5 * time.Second),
+		},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "graceful"))
+
+	// Give the shell a moment to install its signal handlers
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, manager.StopApp(context.Background(), "graceful"))
+
+	// The application is given time to clean up rather than being killed immediately
+	app, err := manager.GetApp("graceful")
+	require.NoError(t, err)
+	assert.Equal(t, StateStopping, app.State)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, manager.WaitForExit(ctx, "graceful"))
+
+	app, err = manager.GetApp("graceful")
+	require.NoError(t, err)
+	assert.Equal(t, StateNotRunning, app.State)
+	assert.Equal(t, 0, app.LastExitCode)
+
+	require.Eventually(t, func() bool {
+		return findAuditLog(t, manager, "graceful", "Application stopped gracefully after")
+	}, time.Second, 10*time.Millisecond)
+	assert.False(t, findAuditLog(t, manager, "graceful", "SIGKILL"))
+}
+
+func TestStopTimeoutEscalation(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:        "stubborn",
+			Path:        "/bin/sh",
+			Args:        []string{"-c", "trap '' TERM; while true; do sleep 0.05; done"},
+			StopSignal:  "SIGTERM",
+			StopTimeout: config.Duration(200 * time.Millisecond),
+		},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "stubborn"))
+
+	// Give the shell a moment to install its signal handlers
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, manager.StopApp(context.Background(), "stubborn"))
+
+	// Starting an application which is still stopping is not allowed
+	err := manager.StartApp(context.Background(), "stubborn")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "still stopping")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, manager.WaitForExit(ctx, "stubborn"))
+
+	require.Eventually(t, func() bool {
+		return findAuditLog(t, manager, "stubborn", "Application stopped after") &&
+			findAuditLog(t, manager, "stubborn", "(escalated to SIGKILL)")
+	}, time.Second, 10*time.Millisecond)
+	assert.True(t, findAuditLog(t, manager, "stubborn", "Application did not stop within 200ms, escalating to SIGKILL"))
+}
+
+func TestForceStopWhileStopping(t *testing.T) {
+	configs := []config.ApplicationConfig{
+		{
+			Name:        "stubborn",
+			Path:        "/bin/sh",
+			Args:        []string{"-c", "trap '' TERM; while true; do sleep 0.05; done"},
+			StopSignal:  "SIGTERM",
+			StopTimeout: config.Duration(time.Minute),
+		},
+	}
+
+	manager := NewManager(configs)
+	require.NoError(t, manager.StartApp(context.Background(), "stubborn"))
+
+	// Give the shell a moment to install its signal handlers
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, manager.StopApp(context.Background(), "stubborn"))
+	require.NoError(t, manager.ForceStopApp(context.Background(), "stubborn"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, manager.WaitForExit(ctx, "stubborn"))
+
+	require.Eventually(t, func() bool {
+		return findAuditLog(t, manager, "stubborn", "(force stopped)")
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,111 @@
+package apps
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// The number of log lines which may be queued for a subscriber before new lines are dropped
+const subscriptionQueueSize = 256
+
+// LogSubscription delivers an application's log lines to a subscriber as they are logged.
+// Lines are queued for the subscriber, and if it falls too far behind any further lines
+// are dropped (and counted) rather than holding up the application.
+type LogSubscription struct {
+	lines   chan LogLine
+	dropped atomic.Uint64
+	app     *Application
+	once    sync.Once
+}
+
+// Lines returns the channel which new log lines are delivered on. It is closed once the
+// subscription is closed.
+func (s *LogSubscription) Lines() <-chan LogLine {
+	return s.lines
+}
+
+// Dropped returns the number of lines which were dropped because the subscriber's queue was
+// full since the last time it was called.
+func (s *LogSubscription) Dropped() uint64 {
+	return s.dropped.Swap(0)
+}
+
+// Close stops delivering log lines to the subscriber
+func (s *LogSubscription) Close() {
+	s.once.Do(func() {
+		s.app.logMux.Lock()
+		delete(s.app.subscribers, s)
+		s.app.logMux.Unlock()
+
+		close(s.lines)
+	})
+}
+
+// SubscribeLogs returns the log lines selected by the query, followed by any which were
+// logged while they were being read, along with a subscription which delivers every line
+// logged afterwards. The subscription must be closed once it is no longer needed.
+func (m *Manager) SubscribeLogs(name string, query LogQuery) ([]LogLine, *LogSubscription, error) {
+	lines, err := m.QueryLogs(name, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m.mux.RLock()
+	app := m.apps[name]
+	m.mux.RUnlock()
+
+	app.logMux.Lock()
+	defer app.logMux.Unlock()
+
+	// Catch up on the lines which were logged since the query was made, so that there is
+	// no gap before the subscription's first line.
+	if query.Before == 0 {
+		after := query.After
+		if len(lines) > 0 {
+			after = lines[len(lines)-1].Seq
+		}
+
+		lines = append(lines, app.logsAfter(after)...)
+	}
+
+	sub := &LogSubscription{
+		lines: make(chan LogLine, subscriptionQueueSize),
+		app:   app,
+	}
+
+	if app.subscribers == nil {
+		app.subscribers = make(map[*LogSubscription]struct{})
+	}
+	app.subscribers[sub] = struct{}{}
+
+	return lines, sub, nil
+}
+
+// logsAfter returns the buffered lines with a sequence number greater than after. The
+// application's log lock must be held while calling it.
+func (a *Application) logsAfter(after uint64) []LogLine {
+	// New lines are usually only found at the end of the buffer
+	start := a.logs.len()
+	for start > 0 && a.logs.at(start-1).Seq > after {
+		start--
+	}
+
+	lines := make([]LogLine, 0, a.logs.len()-start)
+	for i := start; i < a.logs.len(); i++ {
+		lines = append(lines, a.logs.at(i))
+	}
+
+	return lines
+}
+
+// publish delivers the log line to each of the application's subscribers without
+// waiting for them. The application's log lock must be held while calling it.
+func (a *Application) publish(line LogLine) {
+	for sub := range a.subscribers {
+		select {
+		case sub.lines <- line:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
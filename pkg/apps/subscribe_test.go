@@ -0,0 +1,74 @@
+package apps
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribeLogs(t *testing.T) {
+	manager := NewManager([]config.ApplicationConfig{
+		{Name: "app", Path: "/bin/true"},
+	})
+	app := manager.apps["app"]
+
+	for i := 1; i <= 3; i++ {
+		manager.addLogLine(app, LogLine{Timestamp: time.Now(), Message: strconv.Itoa(i), Source: "stdout"})
+	}
+
+	backlog, sub, err := manager.SubscribeLogs("app", LogQuery{After: 1})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	require.Len(t, backlog, 2)
+	assert.Equal(t, "2", backlog[0].Message)
+	assert.Equal(t, "3", backlog[1].Message)
+
+	manager.addLogLine(app, LogLine{Timestamp: time.Now(), Message: "4", Source: "stdout"})
+
+	select {
+	case line := <-sub.Lines():
+		assert.Equal(t, "4", line.Message)
+		assert.Equal(t, uint64(4), line.Seq)
+	case <-time.After(time.Second):
+		t.Fatal("the subscriber did not receive the new line")
+	}
+
+	assert.Zero(t, sub.Dropped())
+
+	_, _, err = manager.SubscribeLogs("missing", LogQuery{})
+	assert.Error(t, err)
+}
+
+func TestSubscribeLogsDropsWhenFull(t *testing.T) {
+	manager := NewManager([]config.ApplicationConfig{
+		{Name: "app", Path: "/bin/true"},
+	})
+	app := manager.apps["app"]
+
+	_, sub, err := manager.SubscribeLogs("app", LogQuery{})
+	require.NoError(t, err)
+
+	// A subscriber which isn't reading must not hold up the application
+	for i := 0; i < subscriptionQueueSize+10; i++ {
+		manager.addLogLine(app, LogLine{Timestamp: time.Now(), Message: strconv.Itoa(i), Source: "stdout"})
+	}
+
+	assert.Len(t, sub.Lines(), subscriptionQueueSize)
+	assert.Equal(t, uint64(10), sub.Dropped())
+	assert.Zero(t, sub.Dropped(), "the dropped count is reset once it has been read")
+
+	sub.Close()
+	sub.Close()
+	assert.Empty(t, app.subscribers)
+
+	// Lines logged after the subscription is closed are not delivered
+	manager.addLogLine(app, LogLine{Timestamp: time.Now(), Message: "late", Source: "stdout"})
+	for line := range sub.Lines() {
+		assert.NotEqual(t, "late", line.Message)
+	}
+}
@@ -3,6 +3,10 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/sierrasoftworks/tailon/pkg/userctx"
 	"gopkg.in/yaml.v3"
@@ -16,6 +20,61 @@ type Config struct {
 	Tailscale TailscaleConfig `json:"tailscale" yaml:"tailscale"`
 	// Security configuration
 	Security SecurityConfig `json:"security" yaml:"security"`
+	// How long to wait for applications to stop gracefully when tailon shuts down (default: 30s)
+	ShutdownTimeout Duration `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	// Destinations which every application's logs are forwarded to
+	LogSinks []LogSinkConfig `json:"log_sinks,omitempty" yaml:"log_sinks"`
+	// The directory which tailon keeps its state in, so that it can adopt the applications which
+	// were still running when it last stopped (state is not kept if unset)
+	DataDir string `json:"data_dir,omitempty" yaml:"data_dir"`
+	// What happens to the running applications when tailon shuts down (default: stop)
+	ShutdownMode ShutdownMode `json:"shutdown_mode,omitempty" yaml:"shutdown_mode"`
+}
+
+// ShutdownMode determines what happens to the running applications when tailon shuts down
+type ShutdownMode string
+
+const (
+	// Stop every application when tailon shuts down (default)
+	ShutdownStop ShutdownMode = "stop"
+	// Leave applications running when tailon shuts down, so that it adopts them when it starts
+	// again (requires a data_dir)
+	ShutdownKeepRunning ShutdownMode = "keep-running"
+)
+
+func (m ShutdownMode) IsValid() bool {
+	switch m {
+	case "", ShutdownStop, ShutdownKeepRunning:
+		return true
+	default:
+		return false
+	}
+}
+
+// DefaultShutdownTimeout is used when no shutdown_timeout is configured
+const DefaultShutdownTimeout = 30 * time.Second
+
+// GetShutdownTimeout returns the configured shutdown timeout, or the default if none is set
+func (c *Config) GetShutdownTimeout() time.Duration {
+	if c.ShutdownTimeout > 0 {
+		return c.ShutdownTimeout.Duration()
+	}
+
+	return DefaultShutdownTimeout
+}
+
+// GetNodeName returns the name which identifies this instance of tailon in forwarded logs:
+// its Tailscale name if Tailscale is enabled, or otherwise the machine's hostname.
+func (c *Config) GetNodeName() string {
+	if c.Tailscale.Enabled && c.Tailscale.Name != "" {
+		return c.Tailscale.Name
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		return hostname
+	}
+
+	return "tailon"
 }
 
 type ApplicationConfig struct {
@@ -25,6 +84,316 @@ type ApplicationConfig struct {
 	Env        []string `json:"env" yaml:"env"`
 	WorkingDir string   `json:"working_dir" yaml:"working_dir"` // Working directory for the application
 	StopSignal string   `json:"stop_signal" yaml:"stop_signal"` // Signal to use for stopping (default: SIGINT)
+	// How long to wait for the application to exit after sending its stop signal before killing it (default: 10s)
+	StopTimeout Duration `json:"stop_timeout" yaml:"stop_timeout"`
+	// Whether to run the application in its own process group so that signals reach its child processes (default: true, Unix only)
+	ProcessGroup *bool `json:"process_group,omitempty" yaml:"process_group"`
+
+	// Whether the application should be started automatically when tailon starts
+	Autostart bool `json:"autostart,omitempty" yaml:"autostart"`
+	// How long to wait after tailon starts before automatically starting the application
+	AutostartDelay Duration `json:"autostart_delay" yaml:"autostart_delay"`
+
+	// The policy used to decide whether the application should be restarted when it exits
+	RestartPolicy RestartPolicy `json:"restart_policy,omitempty" yaml:"restart_policy"`
+	// Controls how quickly, and how many times, the application is restarted
+	RestartBackoff RestartBackoffConfig `json:"restart_backoff" yaml:"restart_backoff"`
+	// Controls when repeated restarts are considered to be a crash loop
+	CrashLoop CrashLoopConfig `json:"crash_loop" yaml:"crash_loop"`
+
+	// The names of applications which must be running (and ready) before this application is started
+	DependsOn []string `json:"depends_on,omitempty" yaml:"depends_on"`
+
+	// A regular expression matched against the application's output which indicates that it is ready
+	ReadyWhen string `json:"ready_when,omitempty" yaml:"ready_when"`
+	// How long the application has to become ready before its start is considered to have failed (default: 1m)
+	ReadyTimeout Duration `json:"ready_timeout" yaml:"ready_timeout"`
+
+	// An optional check used to determine whether the running application is healthy
+	HealthCheck *HealthCheckConfig `json:"health_check,omitempty" yaml:"health_check"`
+
+	// Controls how many log lines are kept in memory for the application
+	LogBuffer LogBufferConfig `json:"log_buffer" yaml:"log_buffer"`
+	// Persist the application's logs to disk so that they outlive the in-memory buffer and tailon itself
+	LogStorage *LogStorageConfig `json:"log_storage,omitempty" yaml:"log_storage"`
+	// How the application's log lines are parsed into structured fields (default: text)
+	LogFormat LogFormat `json:"log_format,omitempty" yaml:"log_format"`
+	// How ANSI colour codes and other terminal control sequences in the application's output are handled (default: preserve)
+	ANSI ANSIMode `json:"ansi,omitempty" yaml:"ansi"`
+	// The names of the fields which hold the level, message and timestamp of structured log lines
+	LogFields LogFieldsConfig `json:"log_fields" yaml:"log_fields"`
+	// The length, in bytes, beyond which output lines are split into several log lines (default: 64KiB)
+	MaxLogLineLength int `json:"max_log_line_length,omitempty" yaml:"max_log_line_length"`
+	// Destinations which this application's logs are forwarded to, in addition to the global log_sinks
+	LogSinks []LogSinkConfig `json:"log_sinks,omitempty" yaml:"log_sinks"`
+	// Paths or glob patterns of log files written by the application which are followed alongside its
+	// output. Relative paths are resolved against the application's working_dir.
+	LogFiles []string `json:"log_files,omitempty" yaml:"log_files"`
+	// Secrets which are removed from the application's logs before they are kept, streamed or forwarded
+	Redaction RedactionConfig `json:"redaction" yaml:"redaction"`
+}
+
+// RedactionConfig describes the secrets which are replaced with [REDACTED] in an application's logs
+type RedactionConfig struct {
+	// The names of environment variables whose values are secret, taken from the application's
+	// env or otherwise inherited from tailon's environment
+	SecretEnv []string `json:"secret_env,omitempty" yaml:"secret_env"`
+	// Regular expressions matching secrets. If a pattern has capture groups, only the text they
+	// match is redacted, so password=(\S+) keeps "password=" in place.
+	Patterns []string `json:"patterns,omitempty" yaml:"patterns"`
+}
+
+// LogSinkConfig describes a destination which application logs are forwarded to. Exactly one
+// of Syslog, HTTP or OTLP must be configured.
+type LogSinkConfig struct {
+	// Send log lines to a syslog server using RFC 5424
+	Syslog *SyslogSinkConfig `json:"syslog,omitempty" yaml:"syslog"`
+	// POST batches of log lines to a URL as newline delimited JSON
+	HTTP *HTTPSinkConfig `json:"http,omitempty" yaml:"http"`
+	// Export log lines to an OpenTelemetry collector using OTLP/HTTP
+	OTLP *OTLPSinkConfig `json:"otlp,omitempty" yaml:"otlp"`
+
+	// The number of log lines which may be waiting to be sent before new lines are dropped (default: 10000)
+	BufferSize int `json:"buffer_size,omitempty" yaml:"buffer_size"`
+	// The maximum number of log lines sent at once (default: 100)
+	BatchSize int `json:"batch_size,omitempty" yaml:"batch_size"`
+	// How long to wait for a batch to fill up before sending it anyway (default: 1s)
+	FlushInterval Duration `json:"flush_interval" yaml:"flush_interval"`
+	// The number of times sending a batch is retried before it is dropped (default: 5)
+	MaxRetries int `json:"max_retries,omitempty" yaml:"max_retries"`
+	// The delay before the first retry, which doubles with each further attempt (default: 1s)
+	RetryBackoff Duration `json:"retry_backoff" yaml:"retry_backoff"`
+}
+
+type SyslogSinkConfig struct {
+	// The network used to reach the server: udp, tcp or unix (default: udp)
+	Network string `json:"network,omitempty" yaml:"network"`
+	// The host:port of the server, or the path of its socket for unix
+	Address string `json:"address" yaml:"address"`
+	// The syslog facility which log lines are sent with (default: user)
+	Facility string `json:"facility,omitempty" yaml:"facility"`
+}
+
+type HTTPSinkConfig struct {
+	URL string `json:"url" yaml:"url"`
+	// Additional headers sent with each request, such as for authentication
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers"`
+}
+
+type OTLPSinkConfig struct {
+	// The URL of the collector's OTLP/HTTP logs endpoint (e.g. http://localhost:4318/v1/logs)
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+	// Additional headers sent with each request, such as for authentication
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers"`
+}
+
+// syslogFacilities are the names of the syslog facilities which may be configured, by their code
+var syslogFacilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news", "uucp", "cron",
+	"authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// SyslogFacility returns the numeric code of the configured facility
+func (c *SyslogSinkConfig) SyslogFacility() (int, error) {
+	if c.Facility == "" {
+		return 1, nil
+	}
+
+	for code, name := range syslogFacilities {
+		if strings.EqualFold(name, c.Facility) {
+			return code, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown syslog facility %q", c.Facility)
+}
+
+// Validate ensures that exactly one kind of sink has been configured
+func (c *LogSinkConfig) Validate() error {
+	sinks := 0
+	if c.Syslog != nil {
+		sinks++
+		switch c.Syslog.Network {
+		case "", "udp", "tcp", "unix":
+		default:
+			return fmt.Errorf("syslog network must be one of udp, tcp or unix")
+		}
+
+		if c.Syslog.Address == "" {
+			return fmt.Errorf("syslog sink requires an address")
+		}
+
+		if _, err := c.Syslog.SyslogFacility(); err != nil {
+			return err
+		}
+	}
+
+	if c.HTTP != nil {
+		sinks++
+		if c.HTTP.URL == "" {
+			return fmt.Errorf("http sink requires a url")
+		}
+	}
+
+	if c.OTLP != nil {
+		sinks++
+		if c.OTLP.Endpoint == "" {
+			return fmt.Errorf("otlp sink requires an endpoint")
+		}
+	}
+
+	if sinks != 1 {
+		return fmt.Errorf("exactly one of syslog, http or otlp must be configured")
+	}
+
+	if c.BufferSize < 0 || c.BatchSize < 0 || c.MaxRetries < 0 {
+		return fmt.Errorf("buffer_size, batch_size and max_retries must not be negative")
+	}
+
+	return nil
+}
+
+// LogFormat determines how an application's log lines are parsed
+type LogFormat string
+
+const (
+	// Log lines are plain text (default)
+	LogFormatText LogFormat = "text"
+	// Log lines are JSON objects
+	LogFormatJSON LogFormat = "json"
+	// Log lines are sequences of key=value pairs
+	LogFormatLogfmt LogFormat = "logfmt"
+)
+
+func (f LogFormat) IsValid() bool {
+	switch f {
+	case "", LogFormatText, LogFormatJSON, LogFormatLogfmt:
+		return true
+	default:
+		return false
+	}
+}
+
+// ANSIMode determines how terminal control sequences in an application's output are handled
+type ANSIMode string
+
+const (
+	// Log lines are kept exactly as they were written (default)
+	ANSIPreserve ANSIMode = "preserve"
+	// Control sequences are removed from log lines
+	ANSIStrip ANSIMode = "strip"
+	// Control sequences are removed from log lines, with the styles they describe kept as spans
+	ANSIConvert ANSIMode = "convert"
+)
+
+func (m ANSIMode) IsValid() bool {
+	switch m {
+	case "", ANSIPreserve, ANSIStrip, ANSIConvert:
+		return true
+	default:
+		return false
+	}
+}
+
+// LogFieldsConfig names the fields of structured log lines which hold their level, message
+// and timestamp. When a name isn't configured, the most common names for the field are used.
+type LogFieldsConfig struct {
+	// The field holding the line's level (default: level, lvl or severity)
+	Level string `json:"level,omitempty" yaml:"level"`
+	// The field holding the line's message (default: msg or message)
+	Message string `json:"message,omitempty" yaml:"message"`
+	// The field holding the time at which the line was logged (default: time, ts or timestamp)
+	Timestamp string `json:"timestamp,omitempty" yaml:"timestamp"`
+}
+
+// LogBufferConfig limits the size of an application's in-memory log buffer. The oldest
+// lines are discarded once either limit is reached.
+type LogBufferConfig struct {
+	// The maximum number of lines to keep (default: 1000)
+	MaxLines int `json:"max_lines,omitempty" yaml:"max_lines"`
+	// The maximum total size, in bytes, of the kept lines' messages (default: unlimited)
+	MaxBytes int64 `json:"max_bytes,omitempty" yaml:"max_bytes"`
+}
+
+// LogStorageConfig controls where an application's logs are written on disk and how the
+// log files are rotated.
+type LogStorageConfig struct {
+	// The directory in which the application's log files are written
+	Directory string `json:"directory" yaml:"directory"`
+	// The size, in bytes, at which the current log file is rotated (default: 10MiB)
+	MaxBytes int64 `json:"max_bytes,omitempty" yaml:"max_bytes"`
+	// The age at which the current log file is rotated, measured from when its first line was
+	// written rather than from the line's timestamp (default: never)
+	MaxAge Duration `json:"max_age" yaml:"max_age"`
+	// The number of rotated log files which are retained (default: 5)
+	MaxFiles int `json:"max_files,omitempty" yaml:"max_files"`
+}
+
+// UseProcessGroup returns true if the application should be run in its own process group
+func (c ApplicationConfig) UseProcessGroup() bool {
+	return c.ProcessGroup == nil || *c.ProcessGroup
+}
+
+// RestartPolicy determines whether an application is automatically restarted after it exits
+type RestartPolicy string
+
+const (
+	// Never restart the application automatically (default)
+	RestartNever RestartPolicy = "never"
+	// Restart the application only if it exits with a non-zero exit code
+	RestartOnFailure RestartPolicy = "on-failure"
+	// Always restart the application when it exits, regardless of its exit code, and start it
+	// whenever tailon starts, even if it was stopped by a user before tailon restarted
+	RestartAlways RestartPolicy = "always"
+	// Restart the application when it exits, unless it was explicitly stopped by a user
+	RestartUnlessStopped RestartPolicy = "unless-stopped"
+)
+
+func (p RestartPolicy) IsValid() bool {
+	switch p {
+	case "", RestartNever, RestartOnFailure, RestartAlways, RestartUnlessStopped:
+		return true
+	default:
+		return false
+	}
+}
+
+// StartsWithTailon returns true if applications with this policy are started whenever tailon
+// starts, as if they were configured to autostart.
+func (p RestartPolicy) StartsWithTailon() bool {
+	return p == RestartAlways
+}
+
+// ShouldRestart returns true if an application which exited with the provided exit
+// code should be restarted under this policy.
+func (p RestartPolicy) ShouldRestart(exitCode int) bool {
+	switch p {
+	case RestartAlways, RestartUnlessStopped:
+		return true
+	case RestartOnFailure:
+		return exitCode != 0
+	default:
+		return false
+	}
+}
+
+// ShouldRestartUnknownExit returns true if an application whose process exited in an unknown
+// way, such as one which exited while tailon wasn't running, should be restarted under this
+// policy. Only the policies which restart the application however it exits do so.
+func (p RestartPolicy) ShouldRestartUnknownExit() bool {
+	return p == RestartAlways || p == RestartUnlessStopped
+}
+
+type RestartBackoffConfig struct {
+	// The delay before the first restart attempt (default: 1s)
+	InitialDelay Duration `json:"initial_delay" yaml:"initial_delay"`
+	// The maximum delay between restart attempts (default: 1m)
+	MaxDelay Duration `json:"max_delay" yaml:"max_delay"`
+	// The factor by which the delay grows after each consecutive restart (default: 2)
+	Multiplier float64 `json:"multiplier,omitempty" yaml:"multiplier"`
+	// The maximum number of consecutive restarts before giving up (0 means unlimited)
+	MaxRetries int `json:"max_retries,omitempty" yaml:"max_retries"`
 }
 
 type TailscaleConfig struct {
@@ -39,6 +408,88 @@ type SecurityConfig struct {
 	DefaultRole userctx.Role `json:"default_role,omitempty" yaml:"default_role"`
 }
 
+// CrashLoopConfig determines when an application which keeps exiting is parked in the
+// failed state instead of being restarted again.
+type CrashLoopConfig struct {
+	// The number of exits within the window which constitutes a crash loop (default: 5)
+	MaxExits int `json:"max_exits,omitempty" yaml:"max_exits"`
+	// The period of time over which exits are counted (default: 1m)
+	Window Duration `json:"window" yaml:"window"`
+}
+
+// HealthCheckConfig describes how to determine whether a running application is healthy.
+// Exactly one of HTTP, TCP or Exec must be configured.
+type HealthCheckConfig struct {
+	// Perform an HTTP GET request against the application
+	HTTP *HTTPHealthCheck `json:"http,omitempty" yaml:"http"`
+	// Open a TCP connection to the application
+	TCP *TCPHealthCheck `json:"tcp,omitempty" yaml:"tcp"`
+	// Run a command, which is considered healthy if it exits with code 0
+	Exec *ExecHealthCheck `json:"exec,omitempty" yaml:"exec"`
+
+	// How often the check is run (default: 30s)
+	Interval Duration `json:"interval" yaml:"interval"`
+	// How long a single check may take before it is considered to have failed (default: 5s)
+	Timeout Duration `json:"timeout" yaml:"timeout"`
+	// The number of consecutive failures before the application is considered unhealthy (default: 3)
+	Retries int `json:"retries,omitempty" yaml:"retries"`
+	// How long after starting failures are ignored, giving the application time to initialize
+	StartPeriod Duration `json:"start_period" yaml:"start_period"`
+	// Whether the application should be restarted when it becomes unhealthy
+	RestartOnUnhealthy bool `json:"restart_on_unhealthy,omitempty" yaml:"restart_on_unhealthy"`
+}
+
+type HTTPHealthCheck struct {
+	URL string `json:"url" yaml:"url"`
+	// The status code which indicates a healthy application (default: any 2xx or 3xx status)
+	ExpectedStatus int `json:"expected_status,omitempty" yaml:"expected_status"`
+}
+
+type TCPHealthCheck struct {
+	// The host:port to connect to
+	Address string `json:"address" yaml:"address"`
+}
+
+type ExecHealthCheck struct {
+	// The command and its arguments, run with the application's environment and working directory
+	Command []string `json:"command" yaml:"command"`
+}
+
+// Validate ensures that exactly one kind of check has been configured
+func (c *HealthCheckConfig) Validate() error {
+	checks := 0
+	if c.HTTP != nil {
+		checks++
+		if c.HTTP.URL == "" {
+			return fmt.Errorf("http health check requires a url")
+		}
+	}
+
+	if c.TCP != nil {
+		checks++
+		if c.TCP.Address == "" {
+			return fmt.Errorf("tcp health check requires an address")
+		}
+	}
+
+	if c.Exec != nil {
+		checks++
+		if len(c.Exec.Command) == 0 {
+			return fmt.Errorf("exec health check requires a command")
+		}
+	}
+
+	if checks != 1 {
+		return fmt.Errorf("exactly one of http, tcp or exec must be configured")
+	}
+
+	if c.Retries < 0 {
+		return fmt.Errorf("retries must not be negative")
+	}
+
+	return nil
+}
+
 func Load(filename string) (*Config, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
@@ -50,5 +501,155 @@ func Load(filename string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file: %w", err)
+	}
+
 	return &config, nil
 }
+
+// Validate checks the configuration for values which cannot be used
+func (c *Config) Validate() error {
+	for _, app := range c.Applications {
+		if !app.RestartPolicy.IsValid() {
+			return fmt.Errorf("application %s has an unknown restart_policy %q", app.Name, app.RestartPolicy)
+		}
+
+		if app.RestartBackoff.Multiplier != 0 && app.RestartBackoff.Multiplier < 1 {
+			return fmt.Errorf("application %s has a restart_backoff multiplier of %v, which must be at least 1", app.Name, app.RestartBackoff.Multiplier)
+		}
+
+		if app.RestartBackoff.MaxRetries < 0 {
+			return fmt.Errorf("application %s has a negative restart_backoff max_retries", app.Name)
+		}
+
+		if app.CrashLoop.MaxExits < 0 {
+			return fmt.Errorf("application %s has a negative crash_loop max_exits", app.Name)
+		}
+
+		if app.ReadyWhen != "" {
+			if _, err := regexp.Compile(app.ReadyWhen); err != nil {
+				return fmt.Errorf("application %s has an invalid ready_when pattern: %w", app.Name, err)
+			}
+		}
+
+		if app.LogBuffer.MaxLines < 0 || app.LogBuffer.MaxBytes < 0 {
+			return fmt.Errorf("application %s has a negative log_buffer limit", app.Name)
+		}
+
+		if app.LogStorage != nil {
+			if app.LogStorage.Directory == "" {
+				return fmt.Errorf("application %s has a log_storage without a directory", app.Name)
+			}
+
+			if app.LogStorage.MaxBytes < 0 || app.LogStorage.MaxFiles < 0 {
+				return fmt.Errorf("application %s has a negative log_storage limit", app.Name)
+			}
+		}
+
+		if app.MaxLogLineLength < 0 {
+			return fmt.Errorf("application %s has a negative max_log_line_length", app.Name)
+		}
+
+		if !app.LogFormat.IsValid() {
+			return fmt.Errorf("application %s has an unknown log_format %q", app.Name, app.LogFormat)
+		}
+
+		if !app.ANSI.IsValid() {
+			return fmt.Errorf("application %s has an unknown ansi mode %q", app.Name, app.ANSI)
+		}
+
+		for _, pattern := range app.Redaction.Patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("application %s has an invalid redaction pattern: %w", app.Name, err)
+			}
+		}
+
+		for _, pattern := range app.LogFiles {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("application %s has an invalid log_files pattern %q: %w", app.Name, pattern, err)
+			}
+		}
+
+		for _, sink := range app.LogSinks {
+			if err := sink.Validate(); err != nil {
+				return fmt.Errorf("application %s has an invalid log sink: %w", app.Name, err)
+			}
+		}
+
+		if app.HealthCheck != nil {
+			if err := app.HealthCheck.Validate(); err != nil {
+				return fmt.Errorf("application %s has an invalid health_check: %w", app.Name, err)
+			}
+		}
+	}
+
+	for _, sink := range c.LogSinks {
+		if err := sink.Validate(); err != nil {
+			return fmt.Errorf("invalid log sink: %w", err)
+		}
+	}
+
+	if !c.ShutdownMode.IsValid() {
+		return fmt.Errorf("unknown shutdown_mode %q", c.ShutdownMode)
+	}
+
+	// Applications which are left running can only be adopted if tailon keeps its state
+	if c.ShutdownMode == ShutdownKeepRunning && c.DataDir == "" {
+		return fmt.Errorf("shutdown_mode %q requires a data_dir", c.ShutdownMode)
+	}
+
+	return c.validateDependencies()
+}
+
+// validateDependencies ensures that every dependency refers to a configured application
+// and that applications do not (directly or indirectly) depend on themselves.
+func (c *Config) validateDependencies() error {
+	dependsOn := make(map[string][]string, len(c.Applications))
+	for _, app := range c.Applications {
+		dependsOn[app.Name] = app.DependsOn
+	}
+
+	for _, app := range c.Applications {
+		for _, dep := range app.DependsOn {
+			if _, exists := dependsOn[dep]; !exists {
+				return fmt.Errorf("application %s depends on unknown application %s", app.Name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int, len(dependsOn))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("dependency cycle detected: %s", strings.Join(append(path, name), " -> "))
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+		for _, dep := range dependsOn[name] {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+
+		return nil
+	}
+
+	for _, app := range c.Applications {
+		if err := visit(app.Name, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -65,6 +66,548 @@ tailscale:
 			},
 			expectError: false,
 		},
+		{
+			name: "restart policy",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    restart_policy: "on-failure"
+    restart_backoff:
+      initial_delay: "500ms"
+      max_delay: "30s"
+      multiplier: 1.5
+      max_retries: 5
+    crash_loop:
+      max_exits: 3
+      window: "2m"
+`,
+			expected: &Config{
+				Applications: []ApplicationConfig{
+					{
+						Name:          "test-app",
+						Path:          "/bin/echo",
+						RestartPolicy: RestartOnFailure,
+						RestartBackoff: RestartBackoffConfig{
+							InitialDelay: Duration(500 * time.Millisecond),
+							MaxDelay:     Duration(30 * time.Second),
+							Multiplier:   1.5,
+							MaxRetries:   5,
+						},
+						CrashLoop: CrashLoopConfig{
+							MaxExits: 3,
+							Window:   Duration(2 * time.Minute),
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid restart policy",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    restart_policy: "sometimes"
+`,
+			expected:    nil,
+			expectError: true,
+		},
+		{
+			name: "invalid duration",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    restart_backoff:
+      initial_delay: "soon"
+`,
+			expected:    nil,
+			expectError: true,
+		},
+		{
+			name: "health check",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    health_check:
+      http:
+        url: "http://localhost:8080/health"
+        expected_status: 204
+      interval: "10s"
+      timeout: "2s"
+      retries: 2
+      start_period: "30s"
+      restart_on_unhealthy: true
+`,
+			expected: &Config{
+				Applications: []ApplicationConfig{
+					{
+						Name: "test-app",
+						Path: "/bin/echo",
+						HealthCheck: &HealthCheckConfig{
+							HTTP: &HTTPHealthCheck{
+								URL:            "http://localhost:8080/health",
+								ExpectedStatus: 204,
+							},
+							Interval:           Duration(10 * time.Second),
+							Timeout:            Duration(2 * time.Second),
+							Retries:            2,
+							StartPeriod:        Duration(30 * time.Second),
+							RestartOnUnhealthy: true,
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "health check with multiple checks",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    health_check:
+      tcp:
+        address: "localhost:8080"
+      exec:
+        command: ["true"]
+`,
+			expected:    nil,
+			expectError: true,
+		},
+		{
+			name: "ready when",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    ready_when: "listening on :\\d+"
+    ready_timeout: "30s"
+`,
+			expected: &Config{
+				Applications: []ApplicationConfig{
+					{
+						Name:         "test-app",
+						Path:         "/bin/echo",
+						ReadyWhen:    "listening on :\\d+",
+						ReadyTimeout: Duration(30 * time.Second),
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid ready when pattern",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    ready_when: "listening on ("
+`,
+			expected:    nil,
+			expectError: true,
+		},
+		{
+			name: "dependencies",
+			configYAML: `
+applications:
+  - name: "worker"
+    path: "/bin/echo"
+    depends_on: ["queue", "database"]
+  - name: "queue"
+    path: "/bin/echo"
+    depends_on: ["database"]
+  - name: "database"
+    path: "/bin/echo"
+`,
+			expected: &Config{
+				Applications: []ApplicationConfig{
+					{Name: "worker", Path: "/bin/echo", DependsOn: []string{"queue", "database"}},
+					{Name: "queue", Path: "/bin/echo", DependsOn: []string{"database"}},
+					{Name: "database", Path: "/bin/echo"},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "unknown dependency",
+			configYAML: `
+applications:
+  - name: "worker"
+    path: "/bin/echo"
+    depends_on: ["queue"]
+`,
+			expected:    nil,
+			expectError: true,
+		},
+		{
+			name: "dependency cycle",
+			configYAML: `
+applications:
+  - name: "a"
+    path: "/bin/echo"
+    depends_on: ["b"]
+  - name: "b"
+    path: "/bin/echo"
+    depends_on: ["c"]
+  - name: "c"
+    path: "/bin/echo"
+    depends_on: ["a"]
+`,
+			expected:    nil,
+			expectError: true,
+		},
+		{
+			name: "log storage",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    log_storage:
+      directory: "/var/log/tailon"
+      max_bytes: 1048576
+      max_age: "24h"
+      max_files: 3
+`,
+			expected: &Config{
+				Applications: []ApplicationConfig{
+					{
+						Name: "test-app",
+						Path: "/bin/echo",
+						LogStorage: &LogStorageConfig{
+							Directory: "/var/log/tailon",
+							MaxBytes:  1048576,
+							MaxAge:    Duration(24 * time.Hour),
+							MaxFiles:  3,
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "log buffer",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    log_buffer:
+      max_lines: 5000
+      max_bytes: 1048576
+    max_log_line_length: 4096
+`,
+			expected: &Config{
+				Applications: []ApplicationConfig{
+					{
+						Name: "test-app",
+						Path: "/bin/echo",
+						LogBuffer: LogBufferConfig{
+							MaxLines: 5000,
+							MaxBytes: 1048576,
+						},
+						MaxLogLineLength: 4096,
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "log storage without directory",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    log_storage:
+      max_files: 3
+`,
+			expected:    nil,
+			expectError: true,
+		},
+		{
+			name: "log format",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    log_format: "json"
+    log_fields:
+      level: "severity"
+      message: "event"
+      timestamp: "@timestamp"
+`,
+			expected: &Config{
+				Applications: []ApplicationConfig{
+					{
+						Name:      "test-app",
+						Path:      "/bin/echo",
+						LogFormat: LogFormatJSON,
+						LogFields: LogFieldsConfig{
+							Level:     "severity",
+							Message:   "event",
+							Timestamp: "@timestamp",
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "ansi",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    ansi: "convert"
+`,
+			expected: &Config{
+				Applications: []ApplicationConfig{
+					{
+						Name: "test-app",
+						Path: "/bin/echo",
+						ANSI: ANSIConvert,
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "unknown ansi mode",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    ansi: "colourful"
+`,
+			expected:    nil,
+			expectError: true,
+		},
+		{
+			name: "unknown log format",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    log_format: "xml"
+`,
+			expected:    nil,
+			expectError: true,
+		},
+		{
+			name: "data dir",
+			configYAML: `
+data_dir: "/var/lib/tailon"
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+`,
+			expected: &Config{
+				DataDir: "/var/lib/tailon",
+				Applications: []ApplicationConfig{
+					{
+						Name: "test-app",
+						Path: "/bin/echo",
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "keep running on shutdown",
+			configYAML: `
+data_dir: "/var/lib/tailon"
+shutdown_mode: "keep-running"
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+`,
+			expected: &Config{
+				DataDir:      "/var/lib/tailon",
+				ShutdownMode: ShutdownKeepRunning,
+				Applications: []ApplicationConfig{
+					{
+						Name: "test-app",
+						Path: "/bin/echo",
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "keep running on shutdown without a data dir",
+			configYAML: `
+shutdown_mode: "keep-running"
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+`,
+			expectError: true,
+		},
+		{
+			name: "unknown shutdown mode",
+			configYAML: `
+shutdown_mode: "pause"
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+`,
+			expectError: true,
+		},
+		{
+			name: "log sinks",
+			configYAML: `
+log_sinks:
+  - syslog:
+      network: "tcp"
+      address: "localhost:514"
+      facility: "local0"
+    batch_size: 50
+    flush_interval: "500ms"
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    log_sinks:
+      - http:
+          url: "https://logs.example.com/ingest"
+          headers:
+            Authorization: "Bearer secret"
+      - otlp:
+          endpoint: "http://localhost:4318/v1/logs"
+        max_retries: 3
+        retry_backoff: "2s"
+`,
+			expected: &Config{
+				LogSinks: []LogSinkConfig{
+					{
+						Syslog: &SyslogSinkConfig{
+							Network:  "tcp",
+							Address:  "localhost:514",
+							Facility: "local0",
+						},
+						BatchSize:     50,
+						FlushInterval: Duration(500 * time.Millisecond),
+					},
+				},
+				Applications: []ApplicationConfig{
+					{
+						Name: "test-app",
+						Path: "/bin/echo",
+						LogSinks: []LogSinkConfig{
+							{
+								HTTP: &HTTPSinkConfig{
+									URL:     "https://logs.example.com/ingest",
+									Headers: map[string]string{"Authorization": "Bearer secret"},
+								},
+							},
+							{
+								OTLP:         &OTLPSinkConfig{Endpoint: "http://localhost:4318/v1/logs"},
+								MaxRetries:   3,
+								RetryBackoff: Duration(2 * time.Second),
+							},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "log sink with several destinations",
+			configYAML: `
+log_sinks:
+  - syslog:
+      address: "localhost:514"
+    http:
+      url: "https://logs.example.com/ingest"
+`,
+			expected:    nil,
+			expectError: true,
+		},
+		{
+			name: "unknown syslog facility",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    log_sinks:
+      - syslog:
+          address: "localhost:514"
+          facility: "nonsense"
+`,
+			expected:    nil,
+			expectError: true,
+		},
+		{
+			name: "log files",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    log_files:
+      - "/var/log/test-app.log"
+      - "logs/*.log"
+`,
+			expected: &Config{
+				Applications: []ApplicationConfig{
+					{
+						Name:     "test-app",
+						Path:     "/bin/echo",
+						LogFiles: []string{"/var/log/test-app.log", "logs/*.log"},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid log files pattern",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    log_files:
+      - "logs/[.log"
+`,
+			expected:    nil,
+			expectError: true,
+		},
+		{
+			name: "redaction",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    redaction:
+      secret_env: ["API_TOKEN"]
+      patterns:
+        - "password=(\\S+)"
+`,
+			expected: &Config{
+				Applications: []ApplicationConfig{
+					{
+						Name: "test-app",
+						Path: "/bin/echo",
+						Redaction: RedactionConfig{
+							SecretEnv: []string{"API_TOKEN"},
+							Patterns:  []string{`password=(\S+)`},
+						},
+					},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid redaction pattern",
+			configYAML: `
+applications:
+  - name: "test-app"
+    path: "/bin/echo"
+    redaction:
+      patterns: ["password=(\\S+"]
+`,
+			expected:    nil,
+			expectError: true,
+		},
 		{
 			name: "invalid yaml",
 			configYAML: `
@@ -99,6 +642,29 @@ invalid: yaml: content
 	}
 }
 
+func TestValidateDependencyCycle(t *testing.T) {
+	cfg := &Config{
+		Applications: []ApplicationConfig{
+			{Name: "a", DependsOn: []string{"b"}},
+			{Name: "b", DependsOn: []string{"a"}},
+		},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dependency cycle detected: a -> b -> a")
+
+	cfg = &Config{
+		Applications: []ApplicationConfig{
+			{Name: "a", DependsOn: []string{"a"}},
+		},
+	}
+
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dependency cycle detected: a -> a")
+}
+
 func TestConfigLoadNonExistentFile(t *testing.T) {
 	cfg, err := Load("/non/existent/file.yaml")
 	assert.Error(t, err)
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Duration is a time.Duration which is expressed in configuration files (and
+// API responses) using Go's duration syntax, e.g. "1m30s".
+type Duration time.Duration
+
+// Duration returns the value as a time.Duration
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var raw string
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+
+	return d.parse(raw)
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	return d.parse(raw)
+}
+
+func (d *Duration) parse(raw string) error {
+	if raw == "" {
+		*d = 0
+		return nil
+	}
+
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", raw, err)
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
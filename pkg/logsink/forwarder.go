@@ -0,0 +1,210 @@
+package logsink
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+const (
+	defaultBufferSize    = 10000
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+	defaultMaxRetries    = 5
+	defaultRetryBackoff  = time.Second
+	maxRetryBackoff      = 30 * time.Second
+)
+
+// Forwarder buffers records and sends them to a sink in batches, retrying batches which
+// fail to send. Records are dropped, rather than holding up the applications which logged
+// them, if the sink falls too far behind.
+type Forwarder struct {
+	sink          Sink
+	logger        *logrus.Entry
+	batchSize     int
+	flushInterval time.Duration
+	maxRetries    int
+	retryBackoff  time.Duration
+
+	queue   chan Record
+	dropped atomic.Int64
+	mux     sync.RWMutex
+	// Whether Close has been called, after which no more records are accepted
+	closed bool
+	// Cancelled to abandon sending once the caller of Close stops waiting
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewForwarder starts forwarding records to the sink described by the configuration
+func NewForwarder(cfg config.LogSinkConfig) (*Forwarder, error) {
+	sink, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	f := newForwarder(sink, cfg)
+	go f.run()
+	return f, nil
+}
+
+func newForwarder(sink Sink, cfg config.LogSinkConfig) *Forwarder {
+	f := &Forwarder{
+		sink:          sink,
+		logger:        logrus.WithField("sink", describe(cfg)),
+		batchSize:     cfg.BatchSize,
+		flushInterval: cfg.FlushInterval.Duration(),
+		maxRetries:    cfg.MaxRetries,
+		retryBackoff:  cfg.RetryBackoff.Duration(),
+		done:          make(chan struct{}),
+	}
+	f.ctx, f.cancel = context.WithCancel(context.Background())
+
+	bufferSize := cfg.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	f.queue = make(chan Record, bufferSize)
+
+	if f.batchSize <= 0 {
+		f.batchSize = defaultBatchSize
+	}
+
+	if f.flushInterval <= 0 {
+		f.flushInterval = defaultFlushInterval
+	}
+
+	if f.maxRetries <= 0 {
+		f.maxRetries = defaultMaxRetries
+	}
+
+	if f.retryBackoff <= 0 {
+		f.retryBackoff = defaultRetryBackoff
+	}
+
+	return f
+}
+
+// describe returns a short description of the sink for logging purposes
+func describe(cfg config.LogSinkConfig) string {
+	switch {
+	case cfg.Syslog != nil:
+		return "syslog " + cfg.Syslog.Address
+	case cfg.HTTP != nil:
+		return "http " + cfg.HTTP.URL
+	case cfg.OTLP != nil:
+		return "otlp " + cfg.OTLP.Endpoint
+	default:
+		return "unknown"
+	}
+}
+
+// Forward queues the record to be sent without waiting for it
+func (f *Forwarder) Forward(record Record) {
+	f.mux.RLock()
+	defer f.mux.RUnlock()
+
+	if f.closed {
+		return
+	}
+
+	select {
+	case f.queue <- record:
+	default:
+		if f.dropped.Add(1) == 1 {
+			f.logger.Warn("Log sink is falling behind, dropping log lines")
+		}
+	}
+}
+
+func (f *Forwarder) run() {
+	defer close(f.done)
+
+	ticker := time.NewTicker(f.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Record, 0, f.batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			f.send(batch)
+			batch = make([]Record, 0, f.batchSize)
+		}
+	}
+
+	for {
+		select {
+		case record, ok := <-f.queue:
+			if !ok {
+				flush()
+				return
+			}
+
+			batch = append(batch, record)
+			if len(batch) >= f.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// send delivers the batch to the sink, retrying with an increasing delay if it fails
+func (f *Forwarder) send(batch []Record) {
+	backoff := f.retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := f.sink.Send(f.ctx, batch)
+		if err == nil {
+			if dropped := f.dropped.Swap(0); dropped > 0 {
+				f.logger.WithField("dropped", dropped).Info("Log sink caught up")
+			}
+			return
+		}
+
+		if attempt >= f.maxRetries || f.ctx.Err() != nil {
+			f.logger.WithError(err).WithField("lines", len(batch)).Warn("Failed to forward log lines, dropping them")
+			return
+		}
+
+		f.logger.WithError(err).WithField("retry_in", backoff).Debug("Failed to forward log lines, retrying")
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-f.ctx.Done():
+			timer.Stop()
+		}
+
+		backoff = min(2*backoff, maxRetryBackoff)
+	}
+}
+
+// Close stops accepting records and waits for those already queued to be sent, until
+// the context is done, before closing the sink.
+func (f *Forwarder) Close(ctx context.Context) error {
+	f.mux.Lock()
+	if f.closed {
+		f.mux.Unlock()
+		return nil
+	}
+	f.closed = true
+	close(f.queue)
+	f.mux.Unlock()
+
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		// Give up on anything which hasn't been sent yet
+		f.cancel()
+		<-f.done
+	}
+
+	f.cancel()
+	return f.sink.Close()
+}
@@ -0,0 +1,193 @@
+package logsink
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+// testSink records the batches it is sent, failing the first few attempts if asked to
+type testSink struct {
+	mux      sync.Mutex
+	batches  [][]Record
+	failures int
+	attempts int
+	closed   bool
+	// Closed to let Send return, if set
+	release chan struct{}
+}
+
+func (s *testSink) Send(ctx context.Context, records []Record) error {
+	if s.release != nil {
+		select {
+		case <-s.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.attempts++
+	if s.attempts <= s.failures {
+		return errors.New("sink unavailable")
+	}
+
+	s.batches = append(s.batches, append([]Record(nil), records...))
+	return nil
+}
+
+func (s *testSink) Close() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.closed = true
+	return nil
+}
+
+func (s *testSink) records() []Record {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	var records []Record
+	for _, batch := range s.batches {
+		records = append(records, batch...)
+	}
+	return records
+}
+
+func startForwarder(sink Sink, cfg config.LogSinkConfig) *Forwarder {
+	f := newForwarder(sink, cfg)
+	go f.run()
+	return f
+}
+
+func TestForwarderBatches(t *testing.T) {
+	sink := &testSink{}
+	f := startForwarder(sink, config.LogSinkConfig{
+		BatchSize:     3,
+		FlushInterval: config.Duration(time.Hour),
+	})
+
+	for i := 1; i <= 7; i++ {
+		f.Forward(Record{Seq: uint64(i)})
+	}
+
+	// Full batches are sent without waiting for the flush interval
+	require.Eventually(t, func() bool {
+		return len(sink.records()) == 6
+	}, time.Second, 10*time.Millisecond)
+
+	// The rest are sent when the forwarder is closed
+	require.NoError(t, f.Close(context.Background()))
+
+	records := sink.records()
+	require.Len(t, records, 7)
+	for i, record := range records {
+		assert.Equal(t, uint64(i+1), record.Seq)
+	}
+	assert.Len(t, sink.batches, 3)
+	assert.True(t, sink.closed)
+
+	// Records are ignored once the forwarder has been closed
+	f.Forward(Record{Seq: 8})
+	assert.Len(t, sink.records(), 7)
+}
+
+func TestForwarderFlushInterval(t *testing.T) {
+	sink := &testSink{}
+	f := startForwarder(sink, config.LogSinkConfig{
+		BatchSize:     100,
+		FlushInterval: config.Duration(20 * time.Millisecond),
+	})
+	defer f.Close(context.Background())
+
+	f.Forward(Record{Seq: 1})
+
+	require.Eventually(t, func() bool {
+		return len(sink.records()) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestForwarderRetries(t *testing.T) {
+	sink := &testSink{failures: 2}
+	f := startForwarder(sink, config.LogSinkConfig{
+		BatchSize:    1,
+		RetryBackoff: config.Duration(time.Millisecond),
+	})
+	defer f.Close(context.Background())
+
+	f.Forward(Record{Seq: 1})
+
+	require.Eventually(t, func() bool {
+		return len(sink.records()) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 3, sink.attempts)
+}
+
+func TestForwarderGivesUp(t *testing.T) {
+	sink := &testSink{failures: 3}
+	f := startForwarder(sink, config.LogSinkConfig{
+		BatchSize:    1,
+		MaxRetries:   2,
+		RetryBackoff: config.Duration(time.Millisecond),
+	})
+
+	f.Forward(Record{Seq: 1})
+	f.Forward(Record{Seq: 2})
+	require.NoError(t, f.Close(context.Background()))
+
+	// The first record is dropped after its retries are exhausted, but the next is still sent
+	records := sink.records()
+	require.Len(t, records, 1)
+	assert.Equal(t, uint64(2), records[0].Seq)
+}
+
+func TestForwarderDropsWhenFull(t *testing.T) {
+	sink := &testSink{release: make(chan struct{})}
+	f := startForwarder(sink, config.LogSinkConfig{
+		BufferSize: 2,
+		BatchSize:  1,
+	})
+
+	// The first record is held by the blocked sink, the next two fill the buffer and the
+	// rest are dropped
+	f.Forward(Record{Seq: 1})
+	require.Eventually(t, func() bool {
+		return len(f.queue) == 0
+	}, time.Second, time.Millisecond)
+
+	for i := 2; i <= 5; i++ {
+		f.Forward(Record{Seq: uint64(i)})
+	}
+	assert.Equal(t, int64(2), f.dropped.Load())
+
+	close(sink.release)
+	require.NoError(t, f.Close(context.Background()))
+
+	assert.Len(t, sink.records(), 3)
+	assert.Zero(t, f.dropped.Load(), "the dropped count should be reset once the sink catches up")
+}
+
+func TestForwarderCloseTimeout(t *testing.T) {
+	sink := &testSink{release: make(chan struct{})}
+	f := startForwarder(sink, config.LogSinkConfig{BatchSize: 1})
+
+	f.Forward(Record{Seq: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	// A sink which never responds doesn't hold up Close beyond the context's deadline
+	require.NoError(t, f.Close(ctx))
+	assert.Empty(t, sink.records())
+	assert.True(t, sink.closed)
+}
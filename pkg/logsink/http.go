@@ -0,0 +1,77 @@
+package logsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+// httpSinkTimeout bounds how long a single request to an HTTP sink may take
+const httpSinkTimeout = 30 * time.Second
+
+// postBatch sends a request with the body to the URL, returning an error unless the server
+// responds with a 2xx status.
+func postBatch(ctx context.Context, client *http.Client, url, contentType string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Drain the body so that the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("server responded with %s", resp.Status)
+	}
+
+	return nil
+}
+
+// httpSink POSTs batches of records to a URL as newline delimited JSON
+type httpSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func newHTTPSink(cfg *config.HTTPSinkConfig) *httpSink {
+	return &httpSink{
+		url:     cfg.URL,
+		headers: cfg.Headers,
+		client:  &http.Client{Timeout: httpSinkTimeout},
+	}
+}
+
+func (s *httpSink) Send(ctx context.Context, records []Record) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to encode log record: %w", err)
+		}
+	}
+
+	return postBatch(ctx, s.client, s.url, "application/x-ndjson", s.headers, body.Bytes())
+}
+
+func (s *httpSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,67 @@
+package logsink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+func TestHTTPSink(t *testing.T) {
+	var received []Record
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var record Record
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+			received = append(received, record)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink := newHTTPSink(&config.HTTPSinkConfig{
+		URL:     server.URL,
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+	})
+	defer sink.Close()
+
+	second := testRecord
+	second.Seq = 43
+	second.Fields = map[string]string{"user": "alice"}
+	require.NoError(t, sink.Send(context.Background(), []Record{testRecord, second}))
+
+	require.Len(t, received, 2)
+	assert.Equal(t, "web", received[0].App)
+	assert.Equal(t, "server-1", received[0].Node)
+	assert.Equal(t, "stderr", received[0].Source)
+	assert.Equal(t, testRecord.Message, received[0].Message)
+	assert.Equal(t, uint64(43), received[1].Seq)
+	assert.Equal(t, "alice", received[1].Fields["user"])
+}
+
+func TestHTTPSinkErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	sink := newHTTPSink(&config.HTTPSinkConfig{URL: server.URL})
+	defer sink.Close()
+
+	err := sink.Send(context.Background(), []Record{testRecord})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503")
+}
@@ -0,0 +1,132 @@
+package logsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+// otlpSink exports records to an OpenTelemetry collector using the JSON encoding of OTLP/HTTP.
+// Each application is reported as a separate resource.
+type otlpSink struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+func newOTLPSink(cfg *config.OTLPSinkConfig) *otlpSink {
+	return &otlpSink{
+		endpoint: cfg.Endpoint,
+		headers:  cfg.Headers,
+		client:   &http.Client{Timeout: httpSinkTimeout},
+	}
+}
+
+// The subset of the OTLP logs data model which is sent to collectors
+type (
+	otlpLogsRequest struct {
+		ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+	}
+
+	otlpResourceLogs struct {
+		Resource  otlpResource    `json:"resource"`
+		ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+	}
+
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+
+	otlpScopeLogs struct {
+		Scope      otlpScope       `json:"scope"`
+		LogRecords []otlpLogRecord `json:"logRecords"`
+	}
+
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+
+	otlpLogRecord struct {
+		TimeUnixNano   string          `json:"timeUnixNano"`
+		SeverityNumber int             `json:"severityNumber"`
+		SeverityText   string          `json:"severityText,omitempty"`
+		Body           otlpValue       `json:"body"`
+		Attributes     []otlpAttribute `json:"attributes"`
+	}
+
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+
+	otlpValue struct {
+		StringValue string `json:"stringValue"`
+	}
+)
+
+// otlpSeverityNumbers maps syslog severities to the closest OTLP severity numbers
+var otlpSeverityNumbers = [8]int{21, 21, 21, 17, 13, 10, 9, 5}
+
+func stringAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: value}}
+}
+
+func (s *otlpSink) Send(ctx context.Context, records []Record) error {
+	var request otlpLogsRequest
+	resources := make(map[string]int)
+	for _, record := range records {
+		key := record.App + "\x00" + record.Node
+		i, exists := resources[key]
+		if !exists {
+			i = len(request.ResourceLogs)
+			resources[key] = i
+			request.ResourceLogs = append(request.ResourceLogs, otlpResourceLogs{
+				Resource: otlpResource{Attributes: []otlpAttribute{
+					stringAttribute("service.name", record.App),
+					stringAttribute("host.name", record.Node),
+				}},
+				ScopeLogs: []otlpScopeLogs{{Scope: otlpScope{Name: "tailon"}}},
+			})
+		}
+
+		attributes := []otlpAttribute{
+			stringAttribute("log.iostream", record.Source),
+			stringAttribute("tailon.seq", strconv.FormatUint(record.Seq, 10)),
+		}
+
+		keys := make([]string, 0, len(record.Fields))
+		for key := range record.Fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			attributes = append(attributes, stringAttribute(key, record.Fields[key]))
+		}
+
+		scope := &request.ResourceLogs[i].ScopeLogs[0]
+		scope.LogRecords = append(scope.LogRecords, otlpLogRecord{
+			TimeUnixNano:   strconv.FormatInt(record.Timestamp.UnixNano(), 10),
+			SeverityNumber: otlpSeverityNumbers[severity(record)],
+			SeverityText:   record.Level,
+			Body:           otlpValue{StringValue: record.Message},
+			Attributes:     attributes,
+		})
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode log records: %w", err)
+	}
+
+	return postBatch(ctx, s.client, s.endpoint, "application/json", s.headers, body)
+}
+
+func (s *otlpSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
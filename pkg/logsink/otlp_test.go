@@ -0,0 +1,60 @@
+package logsink
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+func TestOTLPSink(t *testing.T) {
+	var request otlpLogsRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+	}))
+	defer server.Close()
+
+	sink := newOTLPSink(&config.OTLPSinkConfig{
+		Endpoint: server.URL + "/v1/logs",
+		Headers:  map[string]string{"X-Api-Key": "secret"},
+	})
+	defer sink.Close()
+
+	worker := testRecord
+	worker.App = "worker"
+	worker.Source = "stdout"
+	worker.Level = "warn"
+	worker.Fields = map[string]string{"queue": "emails"}
+	require.NoError(t, sink.Send(context.Background(), []Record{testRecord, worker, testRecord}))
+
+	// Records are grouped by the application which logged them
+	require.Len(t, request.ResourceLogs, 2)
+
+	web := request.ResourceLogs[0]
+	assert.Equal(t, []otlpAttribute{
+		stringAttribute("service.name", "web"),
+		stringAttribute("host.name", "server-1"),
+	}, web.Resource.Attributes)
+	require.Len(t, web.ScopeLogs, 1)
+	assert.Equal(t, "tailon", web.ScopeLogs[0].Scope.Name)
+	require.Len(t, web.ScopeLogs[0].LogRecords, 2)
+
+	record := web.ScopeLogs[0].LogRecords[0]
+	assert.Equal(t, "1704164645678000000", record.TimeUnixNano)
+	assert.Equal(t, 17, record.SeverityNumber, "stderr should be reported as an error")
+	assert.Equal(t, "something went wrong", record.Body.StringValue)
+	assert.Contains(t, record.Attributes, stringAttribute("log.iostream", "stderr"))
+
+	record = request.ResourceLogs[1].ScopeLogs[0].LogRecords[0]
+	assert.Equal(t, 13, record.SeverityNumber)
+	assert.Equal(t, "warn", record.SeverityText)
+	assert.Contains(t, record.Attributes, stringAttribute("queue", "emails"))
+}
@@ -0,0 +1,73 @@
+package logsink
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+// Record is a log line which is forwarded to a sink
+type Record struct {
+	// The name of the application which logged the line
+	App string `json:"app"`
+	// The name of the tailon instance which is running the application
+	Node      string            `json:"node"`
+	Source    string            `json:"source"`
+	Seq       uint64            `json:"seq"`
+	Timestamp time.Time         `json:"timestamp"`
+	Message   string            `json:"message"`
+	Level     string            `json:"level,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+// Sink is a destination which log records are sent to
+type Sink interface {
+	// Send delivers a batch of records, returning an error if they should be sent again
+	Send(ctx context.Context, records []Record) error
+	// Close releases any connections held by the sink
+	Close() error
+}
+
+// New creates the sink described by the configuration
+func New(cfg config.LogSinkConfig) (Sink, error) {
+	switch {
+	case cfg.Syslog != nil:
+		return newSyslogSink(cfg.Syslog)
+	case cfg.HTTP != nil:
+		return newHTTPSink(cfg.HTTP), nil
+	case cfg.OTLP != nil:
+		return newOTLPSink(cfg.OTLP), nil
+	default:
+		return nil, fmt.Errorf("no log sink is configured")
+	}
+}
+
+// severity maps a record's level, or otherwise its source, to one of the syslog severities
+// (0 is the most severe and 7 the least).
+func severity(record Record) int {
+	switch record.Level {
+	case "fatal", "panic", "crit", "critical":
+		return 2
+	case "error", "err":
+		return 3
+	case "warn", "warning":
+		return 4
+	case "notice":
+		return 5
+	case "info":
+		return 6
+	case "debug", "trace":
+		return 7
+	}
+
+	switch record.Source {
+	case "stderr":
+		return 3
+	case "audit":
+		return 5
+	default:
+		return 6
+	}
+}
@@ -0,0 +1,140 @@
+package logsink
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sierrasoftworks/tailon/pkg/config"
+)
+
+var testRecord = Record{
+	App:       "web",
+	Node:      "server-1",
+	Source:    "stderr",
+	Seq:       42,
+	Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 678000000, time.UTC),
+	Message:   "something went wrong",
+}
+
+func TestSyslogFormat(t *testing.T) {
+	sink, err := newSyslogSink(&config.SyslogSinkConfig{Address: "localhost:514", Facility: "local0"})
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		`<131>1 2024-01-02T03:04:05.678000Z server-1 web - stderr [tailon@32473 app="web" source="stderr" node="server-1"] something went wrong`,
+		string(sink.format(testRecord)))
+
+	record := testRecord
+	record.App = "my app"
+	record.Node = `server"1]`
+	record.Level = "warn"
+	assert.Equal(t,
+		`<132>1 2024-01-02T03:04:05.678000Z server"1] my_app - stderr [tailon@32473 app="my app" source="stderr" node="server\"1\]" level="warn"] something went wrong`,
+		string(sink.format(record)))
+}
+
+func TestSyslogUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	sink, err := newSyslogSink(&config.SyslogSinkConfig{Address: conn.LocalAddr().String()})
+	require.NoError(t, err)
+	defer sink.Close()
+
+	require.NoError(t, sink.Send(context.Background(), []Record{testRecord, testRecord}))
+
+	buf := make([]byte, 1024)
+	for range 2 {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(buf[:n]), "<11>1 "), "each record should be sent as its own datagram: %s", buf[:n])
+		assert.True(t, strings.HasSuffix(string(buf[:n]), "] something went wrong"))
+	}
+}
+
+// readFramedMessage reads a message framed using octet counting
+func readFramedMessage(t *testing.T, reader *bufio.Reader) string {
+	length, err := reader.ReadString(' ')
+	require.NoError(t, err)
+
+	n, err := strconv.Atoi(strings.TrimSpace(length))
+	require.NoError(t, err)
+
+	message := make([]byte, n)
+	_, err = reader.Read(message)
+	require.NoError(t, err)
+	return string(message)
+}
+
+func TestSyslogTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	messages := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		for range 2 {
+			messages <- readFramedMessage(t, reader)
+		}
+	}()
+
+	sink, err := newSyslogSink(&config.SyslogSinkConfig{Network: "tcp", Address: listener.Addr().String()})
+	require.NoError(t, err)
+	defer sink.Close()
+
+	second := testRecord
+	second.Message = "second message"
+	require.NoError(t, sink.Send(context.Background(), []Record{testRecord, second}))
+
+	assert.True(t, strings.HasSuffix(<-messages, "] something went wrong"))
+	assert.True(t, strings.HasSuffix(<-messages, "] second message"))
+}
+
+func TestSyslogUnixgram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenPacket("unixgram", path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	sink, err := newSyslogSink(&config.SyslogSinkConfig{Network: "unix", Address: path})
+	require.NoError(t, err)
+	defer sink.Close()
+
+	require.NoError(t, sink.Send(context.Background(), []Record{testRecord}))
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(buf[:n]), "<11>1 "))
+}
+
+func TestSyslogUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+
+	sink, err := newSyslogSink(&config.SyslogSinkConfig{Network: "tcp", Address: address})
+	require.NoError(t, err)
+
+	assert.Error(t, sink.Send(context.Background(), []Record{testRecord}))
+}
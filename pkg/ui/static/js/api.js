@@ -75,8 +75,13 @@ const API = {
         return await this.request(`/api/v1/apps/${name}/logs`);
     },
 
-    // Create EventSource for log streaming
+    // Get the URL from which all of an application's logs can be downloaded
+    exportLogsURL(name, format = 'text') {
+        return `${this.baseURL}/api/v1/apps/${name}/logs/export?format=${encodeURIComponent(format)}`;
+    },
+
+    // Create EventSource for log streaming, with colours described as styled spans
     createLogStream(name) {
-        return new EventSource(`${this.baseURL}/api/v1/apps/${name}/logs?stream=true`);
+        return new EventSource(`${this.baseURL}/api/v1/apps/${name}/logs?stream=true&spans=true`);
     }
 };
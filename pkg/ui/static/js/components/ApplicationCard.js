@@ -100,11 +100,27 @@ class ApplicationCard {
             case 'running':
                 statusClass = 'running';
                 statusText = 'Running';
+                if (this.app.health && this.app.health.status === 'unhealthy') {
+                    statusClass = 'stopping';
+                    statusText = 'Unhealthy';
+                }
+                break;
+            case 'starting':
+                statusClass = 'stopping';
+                statusText = 'Starting';
                 break;
             case 'stopping':
                 statusClass = 'stopping';
                 statusText = 'Stopping';
                 break;
+            case 'backoff':
+                statusClass = 'stopping';
+                statusText = 'Restarting';
+                break;
+            case 'failed':
+                statusClass = 'stopped';
+                statusText = 'Failed';
+                break;
             case 'not_running':
             default:
                 statusClass = 'stopped';
@@ -112,9 +128,14 @@ class ApplicationCard {
                 break;
         }
         
-        const pidText = this.app.state === 'running' && this.app.pid ? ` (PID: ${this.app.pid})` : '';
-        const exitCodeText = this.app.state === 'not_running' && this.app.last_exit_code !== undefined && this.app.last_exit_code !== 0 
-            ? ` (${this.app.last_exit_code})` : '';
+        const pidText = (this.app.state === 'running' || this.app.state === 'starting') && this.app.pid ? ` (PID: ${this.app.pid})` : '';
+        let exitCodeText = '';
+        if (this.app.state === 'not_running' && this.app.last_exit_signal) {
+            // Processes which were terminated by a signal have no exit code of their own
+            exitCodeText = ` (${this.app.last_exit_signal}${this.app.last_core_dumped ? ', core dumped' : ''})`;
+        } else if (this.app.state === 'not_running' && this.app.last_exit_code !== undefined && this.app.last_exit_code !== 0) {
+            exitCodeText = ` (${this.app.last_exit_code})`;
+        }
 
         return Utils.createElement('div', { className: `app-status ${statusClass}` }, [
             Utils.createElement('span', { className: `status-dot ${statusClass}` }),
@@ -135,16 +156,34 @@ class ApplicationCard {
                 info.push(`Running for ${duration}`);
             } else if (this.app.state === 'not_running') {
                 info.push(`Stopped ${duration} ago`);
+            } else if (this.app.state === 'starting') {
+                info.push(`Starting for ${duration}`);
             } else if (this.app.state === 'stopping') {
                 info.push(`Stopping for ${duration}`);
             }
         }
 
+        if (this.app.state === 'running' && this.app.health) {
+            const failures = this.app.health.consecutive_failures;
+            info.push(failures > 0
+                ? `Health: ${this.app.health.status} (${failures} failed checks)`
+                : `Health: ${this.app.health.status}`);
+        }
+
+        if (this.app.state === 'failed' && this.app.failure) {
+            info.push(this.app.failure.reason);
+        }
+
+        if (this.app.state === 'backoff' && this.app.next_restart_at) {
+            const remaining = Math.max(0, new Date(this.app.next_restart_at) - new Date());
+            info.push(`Restart attempt ${this.app.restart_count} in ${this.formatDuration(remaining)}`);
+        }
+
         if (this.app.state_changed_by && !this.app.state_changed_by.is_anonymous) {
             const user = this.app.state_changed_by;
             const userName = user.display_name || user.login_name || user.id;
             
-            if (this.app.state === 'running') {
+            if (this.app.state === 'running' || this.app.state === 'starting') {
                 info.push(`Started by ${userName}`);
             } else if (this.app.state === 'not_running') {
                 info.push(`Stopped by ${userName}`);
@@ -212,11 +251,17 @@ class ApplicationCard {
     renderActionButtons() {
         const buttons = [];
 
-        if (this.app.state === 'running') {
+        if (this.app.state === 'running' || this.app.state === 'starting') {
             buttons.push(
                 this.createActionButtonWithState('stop', Icons.stop(), 'Stop Application'),
                 this.createActionButtonWithState('restart', Icons.restart(), 'Restart Application')
             );
+        } else if (this.app.state === 'backoff') {
+            // Allow a pending restart to be cancelled or performed immediately
+            buttons.push(
+                this.createActionButtonWithState('start', Icons.play(), 'Start Application'),
+                this.createActionButtonWithState('stop', Icons.stop(), 'Cancel Restart')
+            );
         } else if (this.app.state === 'stopping') {
             // Show force stop button when app is stopping
             buttons.push(
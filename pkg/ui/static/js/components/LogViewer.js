@@ -12,11 +12,18 @@ class LogViewer {
     render() {
         const header = Utils.createElement('div', { className: 'logs-header' }, [
             Utils.createElement('h3', {}, ['Live Logs']),
-            Utils.createElement('button', {
-                className: 'btn btn-sm',
-                onclick: () => this.clearLogs(),
-                dataset: { tooltip: 'Clear Logs' }
-            }, [Icons.clear()])
+            Utils.createElement('div', { className: 'logs-actions' }, [
+                Utils.createElement('button', {
+                    className: 'btn btn-sm',
+                    onclick: () => { window.location.href = API.exportLogsURL(this.appName); },
+                    dataset: { tooltip: 'Download Logs' }
+                }, [Icons.download()]),
+                Utils.createElement('button', {
+                    className: 'btn btn-sm',
+                    onclick: () => this.clearLogs(),
+                    dataset: { tooltip: 'Clear Logs' }
+                }, [Icons.clear()])
+            ])
         ]);
 
         this.container = Utils.createElement('div', {
@@ -48,7 +55,16 @@ class LogViewer {
         try {
             this.eventSource = API.createLogStream(this.appName);
 
+            // Reconnections resume from the last line received, so only the first
+            // connection replaces the placeholder content
+            let connected = false;
             this.eventSource.onopen = () => {
+                if (connected) {
+                    this.appendLog('Reconnected to log stream.', new Date().toISOString(), 'info');
+                    return;
+                }
+
+                connected = true;
                 this.container.innerHTML = '';
                 this.appendLog('Connected to log stream.', new Date().toISOString(), 'info');
             };
@@ -56,7 +72,7 @@ class LogViewer {
             this.eventSource.onmessage = (event) => {
                 try {
                     const logData = JSON.parse(event.data);
-                    this.appendLog(logData.message, logData.timestamp, logData.level, logData.source);
+                    this.appendLog(logData.message, logData.timestamp, logData.level, logData.source, logData.fields, logData.spans);
                 } catch (e) {
                     // Handle plain text messages
                     this.appendLog(event.data, new Date().toISOString());
@@ -93,7 +109,7 @@ class LogViewer {
     }
 
     // Append a log line
-    appendLog(message, timestamp, level = null, source = 'stdout') {
+    appendLog(message, timestamp, level = null, source = 'stdout', fields = null, spans = null) {
         if (!this.container) return;
 
         // Determine source class for styling
@@ -107,12 +123,38 @@ class LogViewer {
         const logLine = Utils.createElement('div', { className: `log-line ${sourceClass}` }, [
             Utils.createElement('span', { className: 'log-timestamp' }, [
                 Utils.formatTimestamp(timestamp)
-            ]),
-            Utils.createElement('span', {
-                className: `log-message${level ? ` log-${level}` : ''}`
-            }, [Utils.escapeHtml(message)])
+            ])
         ]);
 
+        // Lines read from log files are labelled with the file they came from
+        if (source && !['stdout', 'stderr', 'audit'].includes(source)) {
+            logLine.appendChild(Utils.createElement('span', {
+                className: 'log-source',
+                title: source
+            }, [Utils.escapeHtml(source.split('/').pop())]));
+        }
+
+        const messageElement = Utils.createElement('span', {
+            className: `log-message${level ? ` log-${level}` : ''}`
+        }, spans ? [] : [Utils.escapeHtml(message)]);
+
+        // Coloured output is rendered from its spans, rather than interpreting escape sequences
+        if (spans) {
+            spans.forEach(span => messageElement.appendChild(this.renderSpan(span)));
+        }
+
+        logLine.appendChild(messageElement);
+
+        // Show the remaining fields of structured log lines after their message
+        if (fields && Object.keys(fields).length > 0) {
+            const formatted = Object.entries(fields)
+                .map(([key, value]) => `${key}=${value}`)
+                .join(' ');
+            logLine.appendChild(Utils.createElement('span', { className: 'log-fields' }, [
+                Utils.escapeHtml(formatted)
+            ]));
+        }
+
         this.container.appendChild(logLine);
 
         // Auto-scroll to bottom
@@ -125,6 +167,48 @@ class LogViewer {
         }
     }
 
+    // Render a styled span of a log message
+    renderSpan(span) {
+        let fg = span.fg;
+        let bg = span.bg;
+        const classes = ['ansi'];
+
+        if (span.inverse) {
+            [fg, bg] = [bg || 'default-bg', fg || 'default-fg'];
+        }
+
+        // Named colours are styled by class, while RGB colours are applied directly
+        const style = [];
+        if (fg) {
+            if (/^#[0-9a-f]{6}$/.test(fg)) {
+                style.push(`color: ${fg}`);
+            } else {
+                classes.push(`ansi-fg-${fg}`);
+            }
+        }
+
+        if (bg) {
+            if (/^#[0-9a-f]{6}$/.test(bg)) {
+                style.push(`background-color: ${bg}`);
+            } else {
+                classes.push(`ansi-bg-${bg}`);
+            }
+        }
+
+        ['bold', 'dim', 'italic', 'underline', 'strikethrough'].forEach(attribute => {
+            if (span[attribute]) {
+                classes.push(`ansi-${attribute}`);
+            }
+        });
+
+        const attributes = { className: classes.join(' ') };
+        if (style.length > 0) {
+            attributes.style = style.join('; ');
+        }
+
+        return Utils.createElement('span', attributes, [Utils.escapeHtml(span.text)]);
+    }
+
     // Clear logs
     clearLogs() {
         if (this.container) {
@@ -133,6 +133,12 @@ const Icons = {
         { type: 'polyline', attrs: { points: '6,9 12,15 18,9' } }
     ]),
     
+    download: () => Utils.createSVG('0 0 24 24', [
+        { type: 'path', attrs: { d: 'M21 15v4a2 2 0 0 1-2 2H5a2 2 0 0 1-2-2v-4' } },
+        { type: 'polyline', attrs: { points: '7,10 12,15 17,10' } },
+        { type: 'line', attrs: { x1: '12', y1: '15', x2: '12', y2: '3' } }
+    ]),
+
     clear: () => Utils.createSVG('0 0 24 24', [
         { type: 'polyline', attrs: { points: '3,6 5,6 21,6' } },
         { type: 'path', attrs: { d: 'm19,6 v14 a2,2 0 0,1 -2,2 H7 a2,2 0 0,1 -2,-2 V6 m3,0 V4 a2,2 0 0,1 2,-2 h4 a2,2 0 0,1 2,2 v2' } }
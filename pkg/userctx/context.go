@@ -68,6 +68,19 @@ func Anonymous(defaultRole Role) *User {
 	}
 }
 
+// System returns the synthetic user which actions performed by tailon itself
+// (rather than in response to a request) are attributed to.
+func System() *User {
+	return &User{
+		ID:          "$system$",
+		DisplayName: "System",
+		IsAnonymous: false,
+		ApplicationRoles: map[string]Role{
+			"*": RoleAdmin,
+		},
+	}
+}
+
 // NewTailscaleUser creates a user from Tailscale user information
 func NewTailscaleUser(userInfo *TailscaleUserInfo, defaultRole Role) *User {
 	if userInfo == nil {
@@ -167,6 +180,20 @@ func WithDefaultRole(ctx context.Context, defaultRole Role) context.Context {
 	return context.WithValue(ctx, defaultRoleContextKey, defaultRole)
 }
 
+// WithSystemUser returns a context in which actions are attributed to the System user
+func WithSystemUser(ctx context.Context) context.Context {
+	user := System()
+	ctx = WithUser(ctx, user)
+
+	logger := logrus.WithFields(logrus.Fields{
+		"user_id":      user.ID,
+		"user_name":    user.DisplayName,
+		"is_anonymous": user.IsAnonymous,
+	})
+
+	return context.WithValue(ctx, "logger", logger)
+}
+
 // FromContext extracts the user from the context, returning Anonymous if not found
 func FromContext(ctx context.Context) *User {
 	if user, ok := ctx.Value(userContextKey).(*User); ok {
@@ -75,3 +75,16 @@ func TestUserEvent(t *testing.T) {
 	assert.Equal(t, "Started application", event.Details)
 	assert.WithinDuration(t, time.Now(), event.Timestamp, time.Second)
 }
+
+func TestSystemUser(t *testing.T) {
+	ctx := WithSystemUser(context.Background())
+
+	user := FromContext(ctx)
+	assert.False(t, user.IsAnonymous)
+	assert.Equal(t, "$system$", user.ID)
+	assert.Equal(t, "System", user.DisplayName)
+	assert.Equal(t, RoleAdmin, user.GetRole("any-app"))
+
+	logger := GetLoggerFromContext(ctx)
+	assert.Equal(t, "$system$", logger.Data["user_id"])
+}